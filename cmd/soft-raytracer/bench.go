@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"runtime"
+	"time"
+)
+
+func runBench(args []string) error {
+	var opts renderOptions
+	fs := newFlagSet("bench", "")
+	opts.register(fs)
+	runs := fs.Int("runs", 3, "number of timed renders")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return usageError("unexpected arguments %q", fs.Args())
+	}
+	if *runs < 1 {
+		return usageError("runs must be at least 1")
+	}
+
+	s, err := opts.load()
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	c := s.Camera
+	threads := c.Workers
+	if threads == 0 {
+		threads = runtime.NumCPU()
+	}
+	fmt.Printf("%dx%d pixels, %d samples, %d threads\n", c.Width, c.Height, max(c.Samples, 1), threads)
+
+	var best, total time.Duration
+	for i := range *runs {
+		start_time := time.Now()
+		_, err := c.RenderContext(ctx, s.World, nil)
+		if errors.Is(err, context.Canceled) {
+			return errors.New("interrupted")
+		}
+		if err != nil {
+			return err
+		}
+		elapsed_time := time.Since(start_time)
+		fmt.Printf("run %d: %v\n", i+1, elapsed_time)
+
+		total += elapsed_time
+		if i == 0 || elapsed_time < best {
+			best = elapsed_time
+		}
+	}
+
+	samples := float64(c.Width*c.Height) * float64(max(c.Samples, 1))
+	fmt.Printf("best %v, mean %v, %.3f Msamples/s\n",
+		best, total/time.Duration(*runs), samples/best.Seconds()/1e6)
+	return nil
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/novelalex/soft-raytracer/pkg/gfx"
+	"github.com/novelalex/soft-raytracer/pkg/nmath"
+)
+
+// imageDiff summarizes the per channel differences between two images
+type imageDiff struct {
+	max       float64
+	mean      float64
+	rmse      float64
+	differing int
+}
+
+func (d imageDiff) psnr() float64 {
+	if d.rmse == 0 {
+		return math.Inf(1)
+	}
+	return 20 * math.Log10(1/d.rmse)
+}
+
+// compareImages counts the pixels with a channel that differs by more than threshold,
+// and returns an image of the absolute differences
+func compareImages(a, b gfx.Canvas, threshold float64) (imageDiff, gfx.Canvas) {
+	result := gfx.NewCanvas(a.Width(), a.Height())
+	var d imageDiff
+	var sum, sum2 float64
+	for y := range a.Height() {
+		for x := range a.Width() {
+			pa, pb := a.PixelAt(x, y), b.PixelAt(x, y)
+			var delta [3]float64
+			differs := false
+			for i := range 3 {
+				delta[i] = math.Abs(pa.At(i) - pb.At(i))
+				d.max = max(d.max, delta[i])
+				sum += delta[i]
+				sum2 += delta[i] * delta[i]
+				differs = differs || delta[i] > threshold
+			}
+			if differs {
+				d.differing++
+			}
+			result.WritePixel(x, y, nmath.NewColor(delta[0], delta[1], delta[2]))
+		}
+	}
+
+	n := float64(a.Width() * a.Height() * 3)
+	if n > 0 {
+		d.mean = sum / n
+		d.rmse = math.Sqrt(sum2 / n)
+	}
+	return d, result
+}
+
+// runDiff exits like diff(1), 1 if the images differ and 2 for any other
+// problem, including images of different sizes
+func runDiff(args []string) error {
+	fs := newFlagSet("diff", "a b")
+	threshold := fs.Float64("threshold", 0, "largest difference in a channel that still counts as a match")
+	output := fs.String("o", "", "write an image of the absolute differences here")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		return usageError("diff needs two images")
+	}
+
+	a, err := readImage(fs.Arg(0))
+	if err != nil {
+		return &exitStatus{exitUsage, err}
+	}
+	b, err := readImage(fs.Arg(1))
+	if err != nil {
+		return &exitStatus{exitUsage, err}
+	}
+	if a.Width() != b.Width() || a.Height() != b.Height() {
+		// images of different sizes can't be compared pixel by pixel
+		return &exitStatus{exitUsage, fmt.Errorf("images are different sizes, %dx%d and %dx%d",
+			a.Width(), a.Height(), b.Width(), b.Height())}
+	}
+
+	d, diff_image := compareImages(a, b, *threshold)
+	if *output != "" {
+		format, err := formatFromPath(*output)
+		if err == nil {
+			err = writeImage(*output, format, diff_image)
+		}
+		if err != nil {
+			return &exitStatus{exitUsage, err}
+		}
+	}
+
+	fmt.Printf("max %g, mean %g, rmse %g, psnr %.2f dB\n", d.max, d.mean, d.rmse, d.psnr())
+	fmt.Printf("%d of %d pixels differ by more than %g\n", d.differing, a.Width()*a.Height(), *threshold)
+	if d.differing > 0 {
+		return &exitStatus{exitError, nil}
+	}
+	return nil
+}
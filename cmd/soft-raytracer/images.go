@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/novelalex/soft-raytracer/pkg/gfx"
+)
+
+// imageFormats are the formats images can be written in
+var imageFormats = []string{"png", "png16", "ppm", "hdr", "pfm", "exr"}
+
+// formatFromPath guesses the format from the extension of path
+func formatFromPath(path string) (string, error) {
+	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(path), "."))
+	switch ext {
+	case "png", "ppm", "hdr", "pfm", "exr":
+		return ext, nil
+	}
+	return "", fmt.Errorf("can't tell the format of %q from its extension", path)
+}
+
+// isHDR formats store linear radiance instead of display values
+func isHDR(format string) bool {
+	return format == "hdr" || format == "pfm" || format == "exr"
+}
+
+func writeImage(path, format string, c gfx.Canvas) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+
+	switch format {
+	case "png":
+		err = c.EncodePNG(w, gfx.DefaultPNGOptions())
+	case "png16":
+		err = c.EncodePNG(w, gfx.PNGOptions{Depth: 16})
+	case "ppm":
+		_, err = w.Write(c.AsP6PPM())
+	case "hdr":
+		err = c.EncodeHDR(w)
+	case "pfm":
+		err = c.EncodePFM(w)
+	case "exr":
+		err = c.EncodeEXR(w, gfx.EXRZIPCompression)
+	default:
+		err = fmt.Errorf("unknown image format %q", format)
+	}
+
+	if err == nil {
+		err = w.Flush()
+	}
+	if close_err := f.Close(); err == nil {
+		err = close_err
+	}
+	return err
+}
+
+func readImage(path string) (gfx.Canvas, error) {
+	format, err := formatFromPath(path)
+	if err != nil {
+		return gfx.Canvas{}, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return gfx.Canvas{}, err
+	}
+	defer f.Close()
+	r := bufio.NewReader(f)
+
+	var c gfx.Canvas
+	switch format {
+	case "png":
+		c, err = gfx.DecodePNG(r)
+	case "ppm":
+		c, err = gfx.DecodePPM(r)
+	case "hdr":
+		c, err = gfx.DecodeHDR(r)
+	case "pfm":
+		c, err = gfx.DecodePFM(r)
+	case "exr":
+		c, err = gfx.DecodeEXR(r)
+	default:
+		err = fmt.Errorf("can't read %s images", format)
+	}
+	if err != nil {
+		return gfx.Canvas{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return c, nil
+}
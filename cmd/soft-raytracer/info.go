@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/novelalex/soft-raytracer/pkg/geom"
+)
+
+func runInfo(args []string) error {
+	opts := renderOptions{integrator: "whitted"}
+	fs := newFlagSet("info", "")
+	fs.StringVar(&opts.scene, "scene", "", "scene file to describe, the built in demo scene if empty")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return usageError("unexpected arguments %q", fs.Args())
+	}
+
+	s, err := opts.load()
+	if err != nil {
+		return err
+	}
+	name := opts.scene
+	if name == "" {
+		name = "demo scene"
+	}
+
+	c := s.Camera
+	fmt.Println(name)
+	fmt.Printf("camera   %dx%d, %.1f degree field of view, %d samples\n",
+		c.Width, c.Height, c.FOV*180/math.Pi, max(c.Samples, 1))
+
+	lights := map[string]int{}
+	for _, l := range s.World.Lights {
+		lights[typeName(l, "Light")]++
+	}
+	fmt.Printf("lights   %s\n", countSummary(len(s.World.Lights), lights))
+
+	shapes := map[string]int{}
+	bounds := geom.EmptyAABB()
+	for _, o := range s.World.Objects {
+		shapes[typeName(o.Shape, "")]++
+		bounds = bounds.Union(o.Shape.Bounds())
+	}
+	fmt.Printf("objects  %s\n", countSummary(len(s.World.Objects), shapes))
+
+	switch {
+	case bounds.IsEmpty():
+		fmt.Println("bounds   empty")
+	case bounds.IsInfinite():
+		fmt.Println("bounds   unbounded")
+	default:
+		fmt.Printf("bounds   (%g, %g, %g) to (%g, %g, %g)\n",
+			bounds.Min.X, bounds.Min.Y, bounds.Min.Z,
+			bounds.Max.X, bounds.Max.Y, bounds.Max.Z)
+	}
+	return nil
+}
+
+// typeName is the lower case name of the type of v without its package and suffix,
+// so a raytracer.PointLight is "point"
+func typeName(v any, suffix string) string {
+	name := fmt.Sprintf("%T", v)
+	name = name[strings.LastIndex(name, ".")+1:]
+	return strings.ToLower(strings.TrimSuffix(name, suffix))
+}
+
+// countSummary is the total followed by the count of each kind, like "3 (2 sphere, 1 plane)"
+func countSummary(total int, counts map[string]int) string {
+	if total == 0 {
+		return "0"
+	}
+	parts := []string{}
+	for _, name := range slices.Sorted(maps.Keys(counts)) {
+		parts = append(parts, fmt.Sprintf("%d %s", counts[name], name))
+	}
+	return fmt.Sprintf("%d (%s)", total, strings.Join(parts, ", "))
+}
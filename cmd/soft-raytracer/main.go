@@ -1,94 +1,146 @@
+// Command soft-raytracer renders scene files and compares the images it makes.
+//
+// Usage:
+//
+//	soft-raytracer <command> [flags]
+//
+// The commands are render, bench, info and diff, run one with -h for its flags.
+// The exit status is 0 on success, 1 when the command failed and 2 when it was
+// used wrongly. diff follows diff(1): 0 when the images match, 1 when they
+// differ and 2 when they couldn't be compared.
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
-	"log"
-	"math"
 	"os"
-	"time"
+	"strings"
+)
 
-	"github.com/novelalex/soft-raytracer/pkg/geom"
-	"github.com/novelalex/soft-raytracer/pkg/nmath"
-	. "github.com/novelalex/soft-raytracer/pkg/raytracer"
+const (
+	exitOK    = 0
+	exitError = 1
+	exitUsage = 2
 )
 
+// exitStatus is an error that asks for a particular exit status,
+// nothing is printed if err is nil
+type exitStatus struct {
+	code int
+	err  error
+}
+
+func (e *exitStatus) Error() string {
+	if e.err == nil {
+		return fmt.Sprintf("exit status %d", e.code)
+	}
+	return e.err.Error()
+}
+
+func (e *exitStatus) Unwrap() error {
+	return e.err
+}
+
+func usageError(format string, args ...any) error {
+	return &exitStatus{exitUsage, fmt.Errorf(format, args...)}
+}
+
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) error
+}
+
+var commands = []command{
+	{"render", "render a scene to an image", runRender},
+	{"bench", "time repeated renders of a scene", runBench},
+	{"info", "print statistics about a scene", runInfo},
+	{"diff", "compare two images", runDiff},
+}
+
 func main() {
+	os.Exit(run(os.Args[1:]))
+}
 
-	floor_shape := geom.DefaultPlane()
-	floor_shape.Xf = nmath.NewRotationY(45 * math.Pi / 180.0)
-	floor := NewObject(&floor_shape, DefaultMaterial())
-	floor_pattern := geom.NewCheckerPattern(nmath.NewColor(0, 0, 0), nmath.NewColor(1, 1, 1))
-	floor_pattern.Xf = nmath.NewTranslation(0, -0.001, 0) // pattern had arifacts due to rounding at y=0
-	floor.Material.Pattern = &floor_pattern
-	floor.Material.Reflective = 0.1
-
-	ceiling_shape := geom.DefaultPlane()
-	ceiling_shape.Xf = nmath.NewTranslation(0, 3, 0)
-	ceiling := NewObject(&ceiling_shape, DefaultMaterial())
-
-	wall_shape := geom.DefaultPlane()
-	wall_shape.SetTransform(wall_shape.Xf.RotateY(45*math.Pi/180.0).Translate(0, 0, 2).RotateX(90 * math.Pi / 180.0))
-	wall := NewObject(&wall_shape, DefaultMaterial())
-	//wall_pattern := geom.NewRingPattern(nmath.NewColor(0, 0.4, 0), nmath.NewColor(1, 1, 1))
-	wall.Material.Color = nmath.NewColor(0.5, 0, 0.5)
-	//wall.Material.Pattern = &wall_pattern
-	wall.Material.Specular = 0.4
-	wall.Material.Shininess = 4
-
-	middle_shape := geom.DefaultCube()
-	middle_shape.RotateY(45*math.Pi/180.0).Translate(-1.5, 1, 0.5)
-	middle := NewObject(&middle_shape, DefaultMaterial())
-	middle.Material.Diffuse = 0.7
-	middle.Material.Specular = 0.3
-	middle.Material.Reflective = 0
-
-	right_shape := geom.DefaultSphere()
-	right_shape.Translate(1.4, 0.5, -0.4).
-		Scale(0.5, 0.5, 0.5)
-	right := NewObject(&right_shape, DefaultMaterial())
-	right.Material.Color = nmath.NewColor(0.5, 0.5, 0.1)
-	right.Material.Diffuse = 0.7
-	right.Material.Specular = 0.9
-	right.Material.Reflective = 1
-
-	left_shape := geom.DefaultSphere()
-	left_shape.Translate(-0.5, 1, -2.5).
-		Scale(0.5, 0.5, 0.5)
-	left := NewObject(&left_shape, DefaultMaterial())
-	left.Material.Color = nmath.NewColor(0, 0, 0)
-	left.Material.Diffuse = 0.7
-	left.Material.Specular = 0.3
-	left.Material.Transparency = 1.0
-	left.Material.IOR = 1.5
-
-	light := NewPointLight(nmath.NewVec3(-10, 2, -10), nmath.NewColor(1, 1, 1))
-
-	w := NewWorldWith(
-		[]PointLight{light},
-		[]Object{
-			floor, ceiling, wall, right, middle, right, left,
-		},
-	)
-
-	c := NewCamera(600, 600, math.Pi/3.0)
-	c.Transform = nmath.NewVec3(0, 1.5, -7).
-		LookAt(
-			nmath.NewVec3(0, 1, 0),
-			nmath.NewVec3(0, 1, 0),
-		)
-
-	start_time := time.Now()
-
-	canvas := c.Render(w)
-
-	elapsed_time := time.Since(start_time)
-	pixel_count := c.Width * c.Height
-
-	fmt.Println("Rendered", pixel_count, "pixels in", elapsed_time)
-
-	//fmt.Fprint(os.Stdout, canvas.AsPPM())
-	err := os.WriteFile("img.ppm", canvas.AsP6PPM(), 0644)
+func run(args []string) int {
+	if len(args) == 0 {
+		usage()
+		return exitUsage
+	}
+	name := args[0]
+	if name == "-h" || name == "-help" || name == "--help" || name == "help" {
+		usage()
+		return exitOK
+	}
+
+	for _, cmd := range commands {
+		if cmd.name != name {
+			continue
+		}
+		err := runCommand(cmd, args[1:])
+		if err == nil {
+			return exitOK
+		}
+
+		code := exitError
+		var status *exitStatus
+		if errors.As(err, &status) {
+			code = status.code
+			err = status.err
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "soft-raytracer %s: %v\n", name, err)
+		}
+		return code
+	}
+
+	fmt.Fprintf(os.Stderr, "soft-raytracer: unknown command %q\n", name)
+	usage()
+	return exitUsage
+}
+
+// runCommand turns a panic in cmd into an error, so scripts see exitError and
+// a one line message instead of a stack trace with the usage exit status
+func runCommand(cmd command, args []string) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("internal error: %v", r)
+		}
+	}()
+	return cmd.run(args)
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: soft-raytracer <command> [flags]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-8s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "run 'soft-raytracer <command> -h' for the flags of a command")
+}
+
+// newFlagSet makes a flag set for a command that reports errors instead of exiting
+func newFlagSet(name, args_usage string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), strings.TrimSpace("usage: soft-raytracer "+name+" [flags] "+args_usage))
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseFlags parses args, the flag package has already printed
+// the problem and the usage if it returns an error
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	err := fs.Parse(args)
+	if errors.Is(err, flag.ErrHelp) {
+		return &exitStatus{exitOK, nil}
+	}
 	if err != nil {
-		log.Fatal(err)
+		return &exitStatus{exitUsage, nil}
 	}
+	return nil
 }
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/novelalex/soft-raytracer/pkg/gfx"
+	"github.com/novelalex/soft-raytracer/pkg/nmath"
+)
+
+var _ = Describe("run", func() {
+	var dir string
+
+	// quietRun runs the command line with its output thrown away
+	quietRun := func(args ...string) int {
+		null, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+		Expect(err).NotTo(HaveOccurred())
+		defer null.Close()
+
+		stdout, stderr := os.Stdout, os.Stderr
+		os.Stdout, os.Stderr = null, null
+		defer func() {
+			os.Stdout, os.Stderr = stdout, stderr
+		}()
+		return run(args)
+	}
+
+	writeFile := func(name, text string) string {
+		path := filepath.Join(dir, name)
+		Expect(os.WriteFile(path, []byte(text), 0644)).To(Succeed())
+		return path
+	}
+
+	writeCanvas := func(name string, c gfx.Canvas) string {
+		path := filepath.Join(dir, name)
+		Expect(writeImage(path, "ppm", c)).To(Succeed())
+		return path
+	}
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+	})
+
+	DescribeTable("should exit with the status scripts expect",
+		func(args []string, code int) {
+			Expect(quietRun(args...)).To(Equal(code))
+		},
+		Entry("no command", []string{}, exitUsage),
+		Entry("help", []string{"-h"}, exitOK),
+		Entry("unknown command", []string{"paint"}, exitUsage),
+		Entry("help for a command", []string{"render", "-h"}, exitOK),
+		Entry("unknown flag", []string{"render", "-colour"}, exitUsage),
+		Entry("bad flag value", []string{"render", "-width", "wide"}, exitUsage),
+		Entry("extra arguments", []string{"info", "scene.yaml"}, exitUsage),
+		Entry("unknown integrator", []string{"bench", "-integrator", "photon"}, exitUsage),
+		Entry("unknown format", []string{"render", "-o", "img.tga"}, exitUsage),
+		Entry("missing scene", []string{"info", "-scene", "/nonexistent/scene.yaml"}, exitError),
+		Entry("demo scene info", []string{"info"}, exitOK),
+	)
+
+	It("should render a scene to an image", func() {
+		output := filepath.Join(dir, "out.ppm")
+
+		Expect(quietRun("render", "-width", "8", "-height", "4", "-o", output)).To(Equal(exitOK))
+
+		c, err := readImage(output)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(c.Width()).To(Equal(uint(8)))
+		Expect(c.Height()).To(Equal(uint(4)))
+	})
+
+	It("should fail with exitError for a scene that can't be rendered", func() {
+		scene := writeFile("bad.yaml", `
+- add: camera
+  width: 8
+  height: 4
+  from: [0, 0, 0]
+  to: [0, 0, 0]
+`)
+		Expect(quietRun("render", "-scene", scene, "-o", filepath.Join(dir, "out.png"))).To(Equal(exitError))
+		Expect(quietRun("bench", "-scene", scene, "-runs", "1")).To(Equal(exitError))
+		Expect(quietRun("info", "-scene", scene)).To(Equal(exitError))
+	})
+
+	It("should turn a panic in a command into exitError", func() {
+		cmd := command{"crash", "", func([]string) error { panic("oops") }}
+		Expect(runCommand(cmd, nil)).To(MatchError("internal error: oops"))
+	})
+
+	Describe("diff", func() {
+		var a, b, c string
+
+		BeforeEach(func() {
+			image := gfx.NewCanvas(2, 2)
+			image.WritePixel(0, 0, nmath.NewColor(1, 0.5, 0))
+			a = writeCanvas("a.ppm", image)
+			b = writeCanvas("b.ppm", image)
+			image.WritePixel(1, 1, nmath.NewColor(0, 0, 1))
+			c = writeCanvas("c.ppm", image)
+		})
+
+		It("should exit with 0 when the images match", func() {
+			Expect(quietRun("diff", a, b)).To(Equal(exitOK))
+		})
+
+		It("should exit with 1 when the images differ", func() {
+			Expect(quietRun("diff", a, c)).To(Equal(exitError))
+		})
+
+		It("should let differences under the threshold match", func() {
+			Expect(quietRun("diff", "-threshold", "1", a, c)).To(Equal(exitOK))
+		})
+
+		It("should exit with 2 when an image can't be read", func() {
+			garbage := writeFile("garbage.ppm", "P6 not an image")
+			Expect(quietRun("diff", a, garbage)).To(Equal(exitUsage))
+			Expect(quietRun("diff", a, filepath.Join(dir, "missing.ppm"))).To(Equal(exitUsage))
+			Expect(quietRun("diff", a)).To(Equal(exitUsage))
+		})
+
+		It("should exit with 2 when the images are different sizes", func() {
+			d := writeCanvas("d.ppm", gfx.NewCanvas(3, 2))
+			Expect(quietRun("diff", a, d)).To(Equal(exitUsage))
+		})
+
+		It("should write an image of the differences", func() {
+			output := filepath.Join(dir, "diff.ppm")
+
+			Expect(quietRun("diff", "-o", output, a, c)).To(Equal(exitError))
+
+			d, err := readImage(output)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(d.PixelAt(1, 1).B).To(Equal(1.0))
+			Expect(d.PixelAt(0, 0).R).To(Equal(0.0))
+		})
+	})
+})
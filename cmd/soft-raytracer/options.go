@@ -0,0 +1,77 @@
+package main
+
+import (
+	"flag"
+
+	"github.com/novelalex/soft-raytracer/pkg/raytracer"
+	"github.com/novelalex/soft-raytracer/pkg/scene"
+)
+
+// renderOptions are the flags shared by render and bench,
+// zero values keep what the scene file says
+type renderOptions struct {
+	scene      string
+	width      uint
+	height     uint
+	samples    int
+	depth      int
+	threads    int
+	integrator string
+	seed       uint64
+}
+
+func (o *renderOptions) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.scene, "scene", "", "scene file to render, a built in demo scene if empty")
+	fs.UintVar(&o.width, "width", 0, "image width in pixels, keeps the aspect ratio if only one of width and height is set")
+	fs.UintVar(&o.height, "height", 0, "image height in pixels")
+	fs.IntVar(&o.samples, "samples", 0, "samples per pixel")
+	fs.IntVar(&o.depth, "depth", 0, "maximum number of bounces")
+	fs.IntVar(&o.threads, "threads", 0, "number of render threads, one per CPU if zero")
+	fs.StringVar(&o.integrator, "integrator", "whitted", "whitted or path")
+	fs.Uint64Var(&o.seed, "seed", 0, "seed for the random numbers of the samplers and the path tracer")
+}
+
+// load reads the scene and applies the options to its camera
+func (o *renderOptions) load() (*scene.Scene, error) {
+	if o.samples < 0 || o.depth < 0 || o.threads < 0 {
+		return nil, usageError("samples, depth and threads can't be negative")
+	}
+
+	s := demoScene()
+	if o.scene != "" {
+		var err error
+		s, err = scene.LoadFile(o.scene)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	c := &s.Camera
+	switch {
+	case o.width != 0 && o.height != 0:
+		c.Width, c.Height = o.width, o.height
+	case o.width != 0:
+		c.Height = max(1, o.width*c.Height/c.Width)
+		c.Width = o.width
+	case o.height != 0:
+		c.Width = max(1, o.height*c.Width/c.Height)
+		c.Height = o.height
+	}
+	c.ComputePixelSize()
+
+	if o.samples != 0 {
+		c.Samples = o.samples
+	}
+	c.Workers = o.threads
+	c.Seed = o.seed
+
+	switch o.integrator {
+	case "whitted":
+		c.Integrator = raytracer.Whitted{MaxDepth: o.depth}
+	case "path":
+		c.Integrator = raytracer.PathTracer{MaxDepth: o.depth}
+	default:
+		return nil, usageError("unknown integrator %q", o.integrator)
+	}
+	return s, nil
+}
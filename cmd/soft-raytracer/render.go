@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/novelalex/soft-raytracer/pkg/gfx"
+)
+
+func runRender(args []string) error {
+	var opts renderOptions
+	fs := newFlagSet("render", "")
+	opts.register(fs)
+	output := fs.String("o", "img.png", "output image")
+	format := fs.String("format", "", "output format: "+strings.Join(imageFormats, ", ")+", from the extension of -o if empty")
+	exposure := fs.Float64("exposure", 0, "exposure adjustment in stops")
+	tonemap := fs.String("tonemap", "none", "tone mapper: none, clamp, reinhard, reinhard-extended, aces or uncharted2, only for png and ppm")
+	srgb := fs.Bool("srgb", true, "encode png and ppm output with the sRGB transfer function")
+	dither := fs.Bool("dither", false, "dither png and ppm output to hide banding")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return usageError("unexpected arguments %q", fs.Args())
+	}
+
+	if *format == "" {
+		var err error
+		if *format, err = formatFromPath(*output); err != nil {
+			return usageError("%v, use -format", err)
+		}
+	} else if !slices.Contains(imageFormats, *format) {
+		return usageError("unknown format %q", *format)
+	}
+
+	// HDR formats keep the radiance, only the exposure applies to them
+	post := gfx.PostProcess{Exposure: *exposure}
+	if !isHDR(*format) {
+		tone_mapper, err := gfx.ParseToneMapper(*tonemap)
+		if err != nil {
+			return usageError("%v", err)
+		}
+		post.ToneMapper = tone_mapper
+		post.SRGB = *srgb
+		post.Dither = *dither
+		post.Seed = opts.seed
+	}
+
+	s, err := opts.load()
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	c := s.Camera
+	start_time := time.Now()
+	canvas, err := c.RenderContext(ctx, s.World, nil)
+	if errors.Is(err, context.Canceled) {
+		return errors.New("interrupted")
+	}
+	if err != nil {
+		return err
+	}
+	elapsed_time := time.Since(start_time)
+
+	if err := writeImage(*output, *format, post.Apply(canvas)); err != nil {
+		return err
+	}
+	fmt.Fprintln(os.Stderr, "Rendered", c.Width*c.Height, "pixels in", elapsed_time, "to", *output)
+	return nil
+}
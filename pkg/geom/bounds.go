@@ -0,0 +1,160 @@
+package geom
+
+import (
+	"math"
+
+	"github.com/novelalex/soft-raytracer/pkg/nmath"
+)
+
+// AABB is an axis aligned bounding box.
+// An empty box has Min greater than Max so that any union replaces it.
+type AABB struct {
+	Min nmath.Vec3
+	Max nmath.Vec3
+}
+
+func NewAABB(min, max nmath.Vec3) AABB {
+	return AABB{min, max}
+}
+
+func EmptyAABB() AABB {
+	inf := math.Inf(1)
+	return AABB{
+		nmath.NewVec3(inf, inf, inf),
+		nmath.NewVec3(-inf, -inf, -inf),
+	}
+}
+
+func InfiniteAABB() AABB {
+	inf := math.Inf(1)
+	return AABB{
+		nmath.NewVec3(-inf, -inf, -inf),
+		nmath.NewVec3(inf, inf, inf),
+	}
+}
+
+// unitAABB bounds the object space of spheres and cubes
+func unitAABB() AABB {
+	return AABB{
+		nmath.NewVec3(-1, -1, -1),
+		nmath.NewVec3(1, 1, 1),
+	}
+}
+
+func (b AABB) IsEmpty() bool {
+	return b.Min.X > b.Max.X || b.Min.Y > b.Max.Y || b.Min.Z > b.Max.Z
+}
+
+func (b AABB) IsInfinite() bool {
+	return math.IsInf(b.Min.X, 0) || math.IsInf(b.Min.Y, 0) || math.IsInf(b.Min.Z, 0) ||
+		math.IsInf(b.Max.X, 0) || math.IsInf(b.Max.Y, 0) || math.IsInf(b.Max.Z, 0)
+}
+
+func (b AABB) AddPoint(p nmath.Vec3) AABB {
+	return AABB{
+		nmath.NewVec3(min(b.Min.X, p.X), min(b.Min.Y, p.Y), min(b.Min.Z, p.Z)),
+		nmath.NewVec3(max(b.Max.X, p.X), max(b.Max.Y, p.Y), max(b.Max.Z, p.Z)),
+	}
+}
+
+func (b AABB) Union(other AABB) AABB {
+	if other.IsEmpty() {
+		return b
+	}
+	return b.AddPoint(other.Min).AddPoint(other.Max)
+}
+
+// Pad grows the box by d on every side
+func (b AABB) Pad(d float64) AABB {
+	if b.IsEmpty() {
+		return b
+	}
+	pad := nmath.NewVec3(d, d, d)
+	return AABB{b.Min.Sub(pad), b.Max.Add(pad)}
+}
+
+func (b AABB) Contains(p nmath.Vec3) bool {
+	return p.X >= b.Min.X && p.X <= b.Max.X &&
+		p.Y >= b.Min.Y && p.Y <= b.Max.Y &&
+		p.Z >= b.Min.Z && p.Z <= b.Max.Z
+}
+
+func (b AABB) Center() nmath.Vec3 {
+	return b.Min.Add(b.Max).Mult(0.5)
+}
+
+func (b AABB) SurfaceArea() float64 {
+	if b.IsEmpty() {
+		return 0
+	}
+	d := b.Max.Sub(b.Min)
+	return 2.0 * (d.X*d.Y + d.Y*d.Z + d.Z*d.X)
+}
+
+// Transform returns the box enclosing all eight corners of b after m is applied.
+// Boxes with an infinite side can't be transformed meaningfully and stay infinite.
+func (b AABB) Transform(m nmath.Mat4) AABB {
+	if b.IsEmpty() {
+		return b
+	}
+	if b.IsInfinite() {
+		return InfiniteAABB()
+	}
+
+	result := EmptyAABB()
+	for i := range 8 {
+		corner := b.Min
+		if i&1 != 0 {
+			corner.X = b.Max.X
+		}
+		if i&2 != 0 {
+			corner.Y = b.Max.Y
+		}
+		if i&4 != 0 {
+			corner.Z = b.Max.Z
+		}
+		result = result.AddPoint(m.MultV(corner.AsPoint4()).DropW())
+	}
+	return result
+}
+
+// IntersectRay returns where the ray enters and leaves the box,
+// the returned values can be negative if the box is behind the ray origin
+func (b AABB) IntersectRay(r Ray) (float64, float64, bool) {
+	if b.IsEmpty() {
+		return 0, 0, false
+	}
+
+	xtmin, xtmax := axisAlignedBoundingBoxCheckAxis(r.Origin.X, r.Dir.X, b.Min.X, b.Max.X)
+	ytmin, ytmax := axisAlignedBoundingBoxCheckAxis(r.Origin.Y, r.Dir.Y, b.Min.Y, b.Max.Y)
+	ztmin, ztmax := axisAlignedBoundingBoxCheckAxis(r.Origin.Z, r.Dir.Z, b.Min.Z, b.Max.Z)
+
+	tmin := max(xtmin, ytmin, ztmin)
+	tmax := min(xtmax, ytmax, ztmax)
+
+	if tmin > tmax {
+		return 0, 0, false
+	}
+
+	return tmin, tmax, true
+}
+
+// split cuts the box in half across its longest axis
+func (b AABB) split() (AABB, AABB) {
+	d := b.Max.Sub(b.Min)
+	mid := b.Center()
+	left_max := b.Max
+	right_min := b.Min
+	if d.X >= d.Y && d.X >= d.Z {
+		left_max.X, right_min.X = mid.X, mid.X
+	} else if d.Y >= d.Z {
+		left_max.Y, right_min.Y = mid.Y, mid.Y
+	} else {
+		left_max.Z, right_min.Z = mid.Z, mid.Z
+	}
+	return NewAABB(b.Min, left_max), NewAABB(right_min, b.Max)
+}
+
+func (b AABB) containsBox(other AABB) bool {
+	return b.Contains(other.Min) && b.Contains(other.Max)
+}
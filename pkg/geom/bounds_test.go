@@ -0,0 +1,96 @@
+package geom_test
+
+import (
+	"math"
+
+	. "github.com/novelalex/soft-raytracer/pkg/geom"
+	nm "github.com/novelalex/soft-raytracer/pkg/nmath"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("AABB", func() {
+	Describe("Union", func() {
+		It("encloses both boxes", func() {
+			a := NewAABB(nm.NewVec3(-5, -2, 0), nm.NewVec3(7, 4, 4))
+			b := NewAABB(nm.NewVec3(8, -7, -2), nm.NewVec3(14, 2, 8))
+
+			result := a.Union(b)
+
+			Expect(result.Min.ApproxEq(nm.NewVec3(-5, -7, -2))).To(BeTrue())
+			Expect(result.Max.ApproxEq(nm.NewVec3(14, 4, 8))).To(BeTrue())
+		})
+
+		It("ignores empty boxes", func() {
+			a := NewAABB(nm.NewVec3(-1, -1, -1), nm.NewVec3(1, 1, 1))
+
+			result := a.Union(EmptyAABB())
+
+			Expect(result).To(Equal(a))
+		})
+	})
+
+	Describe("Transform", func() {
+		It("encloses a rotated box", func() {
+			b := NewAABB(nm.NewVec3(-1, -1, -1), nm.NewVec3(1, 1, 1))
+			m := nm.NewRotationX(math.Pi / 4).RotateY(math.Pi / 4)
+
+			result := b.Transform(m)
+
+			Expect(result.Min.ApproxEq(nm.NewVec3(-1.4142135, -1.7071067, -1.7071067))).To(BeTrue())
+			Expect(result.Max.ApproxEq(nm.NewVec3(1.4142135, 1.7071067, 1.7071067))).To(BeTrue())
+		})
+
+		It("keeps infinite boxes infinite", func() {
+			result := InfiniteAABB().Transform(nm.NewTranslation(1, 2, 3))
+			Expect(result.IsInfinite()).To(BeTrue())
+		})
+	})
+
+	Describe("IntersectRay", func() {
+		It("works", func() {
+			b := NewAABB(nm.NewVec3(5, -2, 0), nm.NewVec3(11, 4, 7))
+			cases := []struct {
+				origin   nm.Vec3
+				dir      nm.Vec3
+				expected bool
+			}{
+				{nm.NewVec3(15, 1, 2), nm.NewVec3(-1, 0, 0), true},
+				{nm.NewVec3(-5, -1, 4), nm.NewVec3(1, 0, 0), true},
+				{nm.NewVec3(7, 6, 5), nm.NewVec3(0, -1, 0), true},
+				{nm.NewVec3(9, -5, 6), nm.NewVec3(0, 1, 0), true},
+				{nm.NewVec3(8, 2, 12), nm.NewVec3(0, 0, -1), true},
+				{nm.NewVec3(6, 0, -5), nm.NewVec3(0, 0, 1), true},
+				{nm.NewVec3(8, 1, 3.5), nm.NewVec3(0, 0, 1), true},
+				{nm.NewVec3(9, -1, -8), nm.NewVec3(2, 4, 6), false},
+				{nm.NewVec3(8, 3, -4), nm.NewVec3(6, 2, 4), false},
+				{nm.NewVec3(9, -1, -2), nm.NewVec3(4, 6, 2), false},
+				{nm.NewVec3(4, 0, 9), nm.NewVec3(0, 0, -1), false},
+				{nm.NewVec3(8, 6, -1), nm.NewVec3(0, -1, 0), false},
+				{nm.NewVec3(12, 5, 4), nm.NewVec3(-1, 0, 0), false},
+			}
+
+			for _, c := range cases {
+				_, _, ok := b.IntersectRay(NewRay(c.origin, c.dir.Normalize()))
+				Expect(ok).To(Equal(c.expected))
+			}
+		})
+	})
+
+	Describe("Shape Bounds", func() {
+		It("transforms a sphere's unit bounds", func() {
+			s := DefaultSphere()
+			s.Translate(1, 2, 3).Scale(2, 2, 2)
+
+			result := s.Bounds()
+
+			Expect(result.Min.ApproxEq(nm.NewVec3(-1, 0, 1))).To(BeTrue())
+			Expect(result.Max.ApproxEq(nm.NewVec3(3, 4, 5))).To(BeTrue())
+		})
+
+		It("is infinite for planes", func() {
+			p := DefaultPlane()
+			Expect(p.Bounds().IsInfinite()).To(BeTrue())
+		})
+	})
+})
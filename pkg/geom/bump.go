@@ -0,0 +1,133 @@
+package geom
+
+import (
+	"math"
+
+	. "github.com/novelalex/soft-raytracer/pkg/nmath"
+)
+
+// TangentFrame is the orthonormal frame of a surface at a point,
+// Bitangent is Tangent x Normal
+type TangentFrame struct {
+	Tangent   Vec3
+	Bitangent Vec3
+	Normal    Vec3
+}
+
+// NewTangentFrame makes tangent perpendicular to normal before completing the frame,
+// if it is parallel to the normal any perpendicular direction is used instead
+func NewTangentFrame(normal, tangent Vec3) TangentFrame {
+	t := tangent.Sub(normal.Mult(tangent.Dot(normal)))
+	if t.Mag() < F64Epsilon {
+		helper := NewVec3(1, 0, 0)
+		if math.Abs(normal.X) > 0.9 {
+			helper = NewVec3(0, 1, 0)
+		}
+		t = helper.Sub(normal.Mult(helper.Dot(normal)))
+	}
+	t = t.Normalize()
+	return TangentFrame{t, t.Cross(normal), normal}
+}
+
+// ToWorld turns a direction in the frame, with z along the normal, into world space
+func (f TangentFrame) ToWorld(v Vec3) Vec3 {
+	return f.Tangent.Mult(v.X).Add(f.Bitangent.Mult(v.Y)).Add(f.Normal.Mult(v.Z))
+}
+
+// Bump changes the normal used for shading a surface without moving the
+// surface itself. Like a Pattern it is evaluated in its own space, after the
+// object's transform and then its own.
+type Bump interface {
+	// NormalAt is the shading normal at world_point on obj, frame is the
+	// frame of the geometric normal there
+	NormalAt(obj Shape, world_point Vec3, frame TangentFrame) Vec3
+	Transform() Transform
+	SetTransform(Mat4)
+}
+
+// bumpPoint moves world_point into the space of bump b on obj
+func bumpPoint(b Bump, obj Shape, world_point Vec3) Vec3 {
+	object_point := obj.Transform().Inverse().MultV(world_point.AsPoint4())
+	return b.Transform().Inverse().MultV(object_point).DropW()
+}
+
+// HeightFunc is the height of a surface above its geometry at a point, a
+// noise.Noise's At method is one
+type HeightFunc func(p Vec3) float64
+
+// HeightBump tilts the normal down the slope of Height, Scale makes the
+// bumps steeper or, when negative, turns them into dents
+type HeightBump struct {
+	Height HeightFunc
+	Scale  float64
+	Xf     Transform
+}
+
+func NewHeightBump(height HeightFunc, scale float64) HeightBump {
+	return HeightBump{height, scale, IdentityTransform()}
+}
+
+func (b HeightBump) Transform() Transform {
+	return b.Xf
+}
+
+func (b *HeightBump) SetTransform(m Mat4) {
+	b.Xf = NewTransform(m)
+}
+
+// bumpDelta is the step, in world units, used to measure the slope of a height field
+const bumpDelta = 1e-4
+
+func (b HeightBump) NormalAt(obj Shape, world_point Vec3, frame TangentFrame) Vec3 {
+	slope := func(dir Vec3) float64 {
+		step := dir.Mult(bumpDelta)
+		ahead := b.Height(bumpPoint(&b, obj, world_point.Add(step)))
+		behind := b.Height(bumpPoint(&b, obj, world_point.Sub(step)))
+		return (ahead - behind) / (2 * bumpDelta)
+	}
+
+	return frame.ToWorld(NewVec3(
+		-b.Scale*slope(frame.Tangent),
+		-b.Scale*slope(frame.Bitangent),
+		1,
+	)).Normalize()
+}
+
+// NormalMap reads the normal from a texture in tangent space, the red, green
+// and blue channels hold the tangent, bitangent and normal components mapped
+// from [-1, 1] to [0, 1]. Strength scales how far the normals lean.
+type NormalMap struct {
+	Texture  Texture
+	Mapping  UVMapping
+	Strength float64
+	Xf       Transform
+}
+
+func NewNormalMap(texture Texture, mapping UVMapping) NormalMap {
+	return NormalMap{texture, mapping, 1, IdentityTransform()}
+}
+
+func (m NormalMap) Transform() Transform {
+	return m.Xf
+}
+
+func (m *NormalMap) SetTransform(mat Mat4) {
+	m.Xf = NewTransform(mat)
+}
+
+func (m NormalMap) NormalAt(obj Shape, world_point Vec3, frame TangentFrame) Vec3 {
+	mapping := m.Mapping
+	if mapping == nil {
+		mapping = PlanarMapping
+	}
+	c := m.Texture.At(mapping(bumpPoint(&m, obj, world_point)))
+	n := NewVec3(
+		(2*c.R-1)*m.Strength,
+		(2*c.G-1)*m.Strength,
+		2*c.B-1,
+	)
+	if n.Mag() < F64Epsilon {
+		return frame.Normal
+	}
+	return frame.ToWorld(n).Normalize()
+}
@@ -0,0 +1,171 @@
+package geom_test
+
+import (
+	"math"
+
+	. "github.com/novelalex/soft-raytracer/pkg/geom"
+	"github.com/novelalex/soft-raytracer/pkg/gfx"
+	nm "github.com/novelalex/soft-raytracer/pkg/nmath"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("TangentAt", func() {
+	It("goes around the y axis on a sphere, the way spherical mapping increases u", func() {
+		s := DefaultSphere()
+
+		Expect(s.TangentAt(nm.NewVec3(0, 0, -1), NewHit(0)).ApproxEq(nm.NewVec3(1, 0, 0))).To(BeTrue())
+		Expect(s.TangentAt(nm.NewVec3(1, 0, 0), NewHit(0)).ApproxEq(nm.NewVec3(0, 0, 1))).To(BeTrue())
+
+		u0, _ := SphericalMapping(nm.NewVec3(0, 0, -1))
+		u1, _ := SphericalMapping(nm.NewVec3(0.01, 0, -1).Normalize())
+		Expect(u1).To(BeNumerically(">", u0))
+	})
+
+	It("follows the transform of the shape", func() {
+		s := DefaultSphere()
+		s.RotateZ(math.Pi / 2)
+
+		t := s.TangentAt(nm.NewVec3(0, 0, -1), NewHit(0))
+
+		Expect(t.ApproxEq(nm.NewVec3(0, 1, 0))).To(BeTrue())
+	})
+
+	It("lies along the surface of every shape", func() {
+		sphere := DefaultSphere()
+		plane := NewPlane(nm.Mat4Identity())
+		cube := NewCube(nm.Mat4Identity())
+		cylinder := NewTruncatedCylinder(-1, 1, true)
+		cone := NewTruncatedCone(-1, 0, true)
+		triangle := NewTriangle(nm.NewVec3(0, 1, 0), nm.NewVec3(-1, 0, 0), nm.NewVec3(1, 0, 0))
+		cases := []struct {
+			shape Shape
+			point nm.Vec3
+		}{
+			{&sphere, nm.NewVec3(0, 0.6, 0.8)},
+			{&plane, nm.NewVec3(3, 0, -2)},
+			{&cube, nm.NewVec3(1, 0.5, 0.2)},
+			{&cube, nm.NewVec3(-0.3, 0.5, -1)},
+			{&cube, nm.NewVec3(0.3, 1, -0.1)},
+			{&cylinder, nm.NewVec3(0.6, 0.5, 0.8)},
+			{&cylinder, nm.NewVec3(0.2, 1, 0.3)},
+			{&cone, nm.NewVec3(0.6, -1, 0.1)},
+			{&cone, nm.NewVec3(0, -0.5, -0.5)},
+			{&triangle, nm.NewVec3(0, 0.5, 0)},
+		}
+		for _, c := range cases {
+			n := c.shape.NormalAt(c.point, NewHit(0))
+			t := c.shape.TangentAt(c.point, NewHit(0))
+
+			Expect(nm.ApproxEq(t.Mag(), 1)).To(BeTrue())
+			Expect(nm.ApproxEq(t.Dot(n), 0)).To(BeTrue())
+		}
+	})
+
+	It("asks the child of a group", func() {
+		s := DefaultSphere()
+		s.RotateZ(math.Pi / 2)
+		g := NewGroup()
+		g.AddChild(&s)
+		g.Translate(5, 0, 0)
+
+		t := g.TangentAt(nm.NewVec3(5, 0, -1), NewChildHit(&s, NewHit(0)))
+
+		Expect(t.ApproxEq(nm.NewVec3(0, 1, 0))).To(BeTrue())
+	})
+})
+
+var _ = Describe("TangentFrame", func() {
+	It("makes the tangent perpendicular to the normal", func() {
+		f := NewTangentFrame(nm.NewVec3(0, 1, 0), nm.NewVec3(1, 1, 0))
+
+		Expect(f.Tangent.ApproxEq(nm.NewVec3(1, 0, 0))).To(BeTrue())
+		Expect(f.Bitangent.ApproxEq(nm.NewVec3(0, 0, 1))).To(BeTrue())
+		Expect(f.Normal).To(Equal(nm.NewVec3(0, 1, 0)))
+	})
+
+	It("picks a tangent when the one given is along the normal", func() {
+		f := NewTangentFrame(nm.NewVec3(0, 0, 1), nm.NewVec3(0, 0, 2))
+
+		Expect(nm.ApproxEq(f.Tangent.Mag(), 1)).To(BeTrue())
+		Expect(nm.ApproxEq(f.Tangent.Dot(f.Normal), 0)).To(BeTrue())
+		Expect(nm.ApproxEq(f.Bitangent.Dot(f.Normal), 0)).To(BeTrue())
+	})
+
+	It("turns directions in the frame into world directions", func() {
+		f := NewTangentFrame(nm.NewVec3(0, 1, 0), nm.NewVec3(1, 0, 0))
+
+		Expect(f.ToWorld(nm.NewVec3(0, 0, 1)).ApproxEq(nm.NewVec3(0, 1, 0))).To(BeTrue())
+		Expect(f.ToWorld(nm.NewVec3(2, 3, 0)).ApproxEq(nm.NewVec3(2, 0, 3))).To(BeTrue())
+	})
+})
+
+var _ = Describe("HeightBump", func() {
+	plane := NewPlane(nm.Mat4Identity())
+	point := nm.NewVec3(0.3, 0, 0.7)
+	frame := NewTangentFrame(nm.NewVec3(0, 1, 0), plane.TangentAt(point, NewHit(0)))
+
+	It("leaves the normal alone on flat ground", func() {
+		b := NewHeightBump(func(nm.Vec3) float64 { return 0.5 }, 1)
+
+		Expect(b.NormalAt(&plane, point, frame).ApproxEq(nm.NewVec3(0, 1, 0))).To(BeTrue())
+	})
+
+	It("tilts the normal down the slope", func() {
+		b := NewHeightBump(func(p nm.Vec3) float64 { return p.X }, 1)
+
+		n := b.NormalAt(&plane, point, frame)
+
+		Expect(n.ApproxEq(nm.NewVec3(-1, 1, 0).Normalize())).To(BeTrue())
+	})
+
+	It("turns bumps into dents with a negative scale", func() {
+		b := NewHeightBump(func(p nm.Vec3) float64 { return p.Z }, -1)
+
+		n := b.NormalAt(&plane, point, frame)
+
+		Expect(n.ApproxEq(nm.NewVec3(0, 1, 1).Normalize())).To(BeTrue())
+	})
+
+	It("evaluates the height in its own space", func() {
+		b := NewHeightBump(func(p nm.Vec3) float64 { return p.X }, 1)
+		b.SetTransform(nm.NewScaling(2, 2, 2))
+
+		n := b.NormalAt(&plane, point, frame)
+
+		Expect(n.ApproxEq(nm.NewVec3(-0.5, 1, 0).Normalize())).To(BeTrue())
+	})
+})
+
+var _ = Describe("NormalMap", func() {
+	plane := NewPlane(nm.Mat4Identity())
+	point := nm.NewVec3(0.5, 0, 0.5)
+	frame := NewTangentFrame(nm.NewVec3(0, 1, 0), plane.TangentAt(point, NewHit(0)))
+
+	flat := func(c nm.Color) Texture {
+		image := gfx.NewCanvas(1, 1)
+		image.WritePixel(0, 0, c)
+		return NewTexture(image)
+	}
+
+	It("gives the geometric normal for a flat normal map", func() {
+		m := NewNormalMap(flat(nm.NewColor(0.5, 0.5, 1)), PlanarMapping)
+
+		Expect(m.NormalAt(&plane, point, frame).ApproxEq(nm.NewVec3(0, 1, 0))).To(BeTrue())
+	})
+
+	It("reads the normal in tangent space", func() {
+		m := NewNormalMap(flat(nm.NewColor(1, 0.5, 1)), PlanarMapping)
+
+		n := m.NormalAt(&plane, point, frame)
+
+		Expect(n.ApproxEq(nm.NewVec3(1, 1, 0).Normalize())).To(BeTrue())
+	})
+
+	It("scales how far the normal leans by its strength", func() {
+		m := NewNormalMap(flat(nm.NewColor(1, 0.5, 1)), PlanarMapping)
+		m.Strength = 0
+
+		Expect(m.NormalAt(&plane, point, frame).ApproxEq(nm.NewVec3(0, 1, 0))).To(BeTrue())
+	})
+})
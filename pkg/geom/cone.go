@@ -0,0 +1,173 @@
+package geom
+
+import (
+	"math"
+
+	"github.com/novelalex/soft-raytracer/pkg/nmath"
+)
+
+// Cone is a double napped cone around the y axis with its tip at the origin,
+// the radius at height y is |y|. Like Cylinder it is truncated to
+// Minimum < y < Maximum and capped at both ends if Closed
+type Cone struct {
+	Xf      nmath.Transform
+	Minimum float64
+	Maximum float64
+	Closed  bool
+}
+
+func DefaultCone() Cone {
+	return Cone{
+		nmath.IdentityTransform(),
+		math.Inf(-1),
+		math.Inf(1),
+		false,
+	}
+}
+
+func NewCone(t nmath.Mat4) Cone {
+	return Cone{
+		nmath.NewTransform(t),
+		math.Inf(-1),
+		math.Inf(1),
+		false,
+	}
+}
+
+func NewTruncatedCone(minimum, maximum float64, closed bool) Cone {
+	return Cone{
+		nmath.IdentityTransform(),
+		minimum,
+		maximum,
+		closed,
+	}
+}
+
+func (c Cone) Transform() nmath.Transform {
+	return c.Xf
+}
+
+func (c *Cone) SetTransform(m nmath.Mat4) {
+	c.Xf = nmath.NewTransform(m)
+}
+
+func (c *Cone) Translate(x, y, z float64) *Cone {
+	c.SetTransform(c.Xf.Mult(nmath.NewTranslation(x, y, z)))
+	return c
+}
+
+func (c *Cone) Scale(x, y, z float64) *Cone {
+	c.SetTransform(c.Xf.Mult(nmath.NewScaling(x, y, z)))
+	return c
+}
+
+func (c *Cone) Rotate(angle float64, axis nmath.Vec3) *Cone {
+	c.SetTransform(c.Xf.Mult(nmath.NewRotation(angle, axis)))
+	return c
+}
+
+func (c *Cone) RotateX(angle float64) *Cone {
+	c.SetTransform(c.Xf.Mult(nmath.NewRotationX(angle)))
+	return c
+}
+
+func (c *Cone) RotateY(angle float64) *Cone {
+	c.SetTransform(c.Xf.Mult(nmath.NewRotationY(angle)))
+	return c
+}
+
+func (c *Cone) RotateZ(angle float64) *Cone {
+	c.SetTransform(c.Xf.Mult(nmath.NewRotationZ(angle)))
+	return c
+}
+
+func (c Cone) localNormalAt(p nmath.Vec3) nmath.Vec3 {
+	dist := p.X*p.X + p.Z*p.Z
+	if dist < c.Maximum*c.Maximum && p.Y >= c.Maximum-nmath.F64Epsilon {
+		return nmath.NewVec3(0, 1, 0)
+	} else if dist < c.Minimum*c.Minimum && p.Y <= c.Minimum+nmath.F64Epsilon {
+		return nmath.NewVec3(0, -1, 0)
+	}
+
+	y := math.Sqrt(dist)
+	if p.Y > 0 {
+		y = -y
+	}
+	return nmath.NewVec3(p.X, y, p.Z)
+}
+
+func (c Cone) NormalAt(world_point nmath.Vec3, hit Hit) nmath.Vec3 {
+	object_point := c.Xf.Inverse().MultV(world_point.AsPoint4())
+	object_normal := c.localNormalAt(object_point.DropW())
+	world_normal := c.Xf.InverseTranspose().MultV(object_normal.AsVector4())
+	return world_normal.DropW().Normalize()
+}
+
+// TangentAt goes around the sides and along x on the caps
+func (c Cone) TangentAt(world_point nmath.Vec3, hit Hit) nmath.Vec3 {
+	object_point := c.Xf.Inverse().MultV(world_point.AsPoint4()).DropW()
+	n := c.localNormalAt(object_point)
+	if n.X == 0 && n.Z == 0 {
+		return worldTangent(c.Xf, nmath.NewVec3(1, 0, 0))
+	}
+	return worldTangent(c.Xf, tangentAroundY(object_point))
+}
+
+func (c Cone) Bounds() AABB {
+	radius := max(math.Abs(c.Minimum), math.Abs(c.Maximum))
+	local := NewAABB(nmath.NewVec3(-radius, c.Minimum, -radius), nmath.NewVec3(radius, c.Maximum, radius))
+	return local.Transform(c.Xf.Matrix())
+}
+
+func (c Cone) intersectCaps(r Ray, xs []float64) []float64 {
+	if !c.Closed || math.Abs(r.Dir.Y) < nmath.F64Epsilon {
+		return xs
+	}
+
+	t := (c.Minimum - r.Origin.Y) / r.Dir.Y
+	if checkCap(r, t, math.Abs(c.Minimum)) {
+		xs = append(xs, t)
+	}
+
+	t = (c.Maximum - r.Origin.Y) / r.Dir.Y
+	if checkCap(r, t, math.Abs(c.Maximum)) {
+		xs = append(xs, t)
+	}
+	return xs
+}
+
+func (c Cone) localIntersectRay(r Ray) []float64 {
+	xs := []float64{}
+
+	a := r.Dir.X*r.Dir.X - r.Dir.Y*r.Dir.Y + r.Dir.Z*r.Dir.Z
+	b := 2*r.Origin.X*r.Dir.X - 2*r.Origin.Y*r.Dir.Y + 2*r.Origin.Z*r.Dir.Z
+	c0 := r.Origin.X*r.Origin.X - r.Origin.Y*r.Origin.Y + r.Origin.Z*r.Origin.Z
+
+	if math.Abs(a) < nmath.F64Epsilon {
+		// parallel to one of the cone's halves, there is at most one hit
+		if math.Abs(b) >= nmath.F64Epsilon {
+			t := -c0 / (2 * b)
+			y := r.Origin.Y + t*r.Dir.Y
+			if c.Minimum < y && y < c.Maximum {
+				xs = append(xs, t)
+			}
+		}
+	} else {
+		sol := nmath.Solve(a, b, c0)
+		for _, t := range sol {
+			y := r.Origin.Y + t*r.Dir.Y
+			if c.Minimum < y && y < c.Maximum {
+				xs = append(xs, t)
+			}
+		}
+	}
+
+	xs = c.intersectCaps(r, xs)
+	sortHits(xs)
+	return xs
+}
+
+func (c Cone) IntersectRay(r Ray) []Hit {
+	ray := r.Transform(c.Xf.Inverse())
+	return newHits(c.localIntersectRay(ray))
+}
@@ -0,0 +1,79 @@
+package geom_test
+
+import (
+	"math"
+
+	. "github.com/novelalex/soft-raytracer/pkg/geom"
+	nm "github.com/novelalex/soft-raytracer/pkg/nmath"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Cone", func() {
+	Describe("IntersectRay", func() {
+		It("hits both halves of the cone", func() {
+			cases := []struct {
+				origin nm.Vec3
+				dir    nm.Vec3
+				t0, t1 float64
+			}{
+				{nm.NewVec3(0, 0, -5), nm.NewVec3(0, 0, 1), 5, 5},
+				{nm.NewVec3(0, 0, -5), nm.NewVec3(1, 1, 1), 8.66025, 8.66025},
+				{nm.NewVec3(1, 1, -5), nm.NewVec3(-0.5, -1, 1), 4.55006, 49.44994},
+			}
+
+			c := DefaultCone()
+			for _, tc := range cases {
+				xs := c.IntersectRay(NewRay(tc.origin, tc.dir.Normalize()))
+				Expect(xs).NotTo(BeEmpty())
+				Expect(nm.LooseEq(xs[0].T, tc.t0)).To(BeTrue())
+				Expect(nm.LooseEq(xs[len(xs)-1].T, tc.t1)).To(BeTrue())
+			}
+		})
+
+		It("hits a ray parallel to one of its halves once", func() {
+			c := DefaultCone()
+			xs := c.IntersectRay(NewRay(nm.NewVec3(0, 0, -1), nm.NewVec3(0, 1, 1).Normalize()))
+			Expect(xs).To(HaveLen(1))
+			Expect(nm.LooseEq(xs[0].T, 0.35355)).To(BeTrue())
+		})
+
+		It("hits the end caps of closed cones", func() {
+			cases := []struct {
+				origin nm.Vec3
+				dir    nm.Vec3
+				count  int
+			}{
+				{nm.NewVec3(0, 0, -5), nm.NewVec3(0, 1, 0), 0},
+				{nm.NewVec3(0, 0, -0.25), nm.NewVec3(0, 1, 1), 2},
+				{nm.NewVec3(0, 0, -0.25), nm.NewVec3(0, 1, 0), 4},
+			}
+
+			c := NewTruncatedCone(-0.5, 0.5, true)
+			for _, tc := range cases {
+				xs := c.IntersectRay(NewRay(tc.origin, tc.dir.Normalize()))
+				Expect(xs).To(HaveLen(tc.count))
+			}
+		})
+	})
+
+	Describe("NormalAt", func() {
+		It("points away from the surface", func() {
+			c := DefaultCone()
+			expected := nm.NewVec3(1, -math.Sqrt2, 1).Normalize()
+			Expect(c.NormalAt(nm.NewVec3(1, 1, 1), NewHit(0)).ApproxEq(expected)).To(BeTrue())
+
+			expected = nm.NewVec3(-1, 1, 0).Normalize()
+			Expect(c.NormalAt(nm.NewVec3(-1, -1, 0), NewHit(0)).ApproxEq(expected)).To(BeTrue())
+		})
+	})
+
+	Describe("Bounds", func() {
+		It("uses the widest end as the radius", func() {
+			c := NewTruncatedCone(-5, 3, false)
+			b := c.Bounds()
+			Expect(b.Min.ApproxEq(nm.NewVec3(-5, -5, -5))).To(BeTrue())
+			Expect(b.Max.ApproxEq(nm.NewVec3(5, 3, 5))).To(BeTrue())
+		})
+	})
+})
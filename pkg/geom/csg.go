@@ -0,0 +1,146 @@
+package geom
+
+import (
+	"sort"
+
+	"github.com/novelalex/soft-raytracer/pkg/nmath"
+)
+
+type CSGOperation int
+
+const (
+	CSGUnion CSGOperation = iota
+	CSGIntersection
+	CSGDifference
+)
+
+// CSG combines two shapes with a constructive solid geometry operation.
+// Only the hits that lie on the surface of the combined solid are returned,
+// so the hits still alternate between entering and leaving it.
+type CSG struct {
+	Xf    nmath.Transform
+	Op    CSGOperation
+	Left  Shape
+	Right Shape
+}
+
+func NewCSG(op CSGOperation, left, right Shape) CSG {
+	return CSG{
+		nmath.IdentityTransform(),
+		op,
+		left,
+		right,
+	}
+}
+
+func (c CSG) Transform() nmath.Transform {
+	return c.Xf
+}
+
+func (c *CSG) SetTransform(m nmath.Mat4) {
+	c.Xf = nmath.NewTransform(m)
+}
+
+func (c *CSG) Translate(x, y, z float64) *CSG {
+	c.SetTransform(c.Xf.Mult(nmath.NewTranslation(x, y, z)))
+	return c
+}
+
+func (c *CSG) Scale(x, y, z float64) *CSG {
+	c.SetTransform(c.Xf.Mult(nmath.NewScaling(x, y, z)))
+	return c
+}
+
+func (c *CSG) Rotate(angle float64, axis nmath.Vec3) *CSG {
+	c.SetTransform(c.Xf.Mult(nmath.NewRotation(angle, axis)))
+	return c
+}
+
+func (c *CSG) RotateX(angle float64) *CSG {
+	c.SetTransform(c.Xf.Mult(nmath.NewRotationX(angle)))
+	return c
+}
+
+func (c *CSG) RotateY(angle float64) *CSG {
+	c.SetTransform(c.Xf.Mult(nmath.NewRotationY(angle)))
+	return c
+}
+
+func (c *CSG) RotateZ(angle float64) *CSG {
+	c.SetTransform(c.Xf.Mult(nmath.NewRotationZ(angle)))
+	return c
+}
+
+// Bounds of a difference can't be bigger than the left shape, the other
+// operations use both shapes
+func (c CSG) Bounds() AABB {
+	local := c.Left.Bounds()
+	if c.Op != CSGDifference {
+		local = local.Union(c.Right.Bounds())
+	}
+	return local.Transform(c.Xf.Matrix())
+}
+
+// csgAllowed decides if a hit on one operand is on the surface of the result.
+// left_hit is true when the hit belongs to Left, in_left and in_right track
+// whether the ray is currently inside each operand.
+func csgAllowed(op CSGOperation, left_hit, in_left, in_right bool) bool {
+	switch op {
+	case CSGUnion:
+		return (left_hit && !in_right) || (!left_hit && !in_left)
+	case CSGIntersection:
+		return (left_hit && in_right) || (!left_hit && in_left)
+	case CSGDifference:
+		return (left_hit && !in_right) || (!left_hit && in_left)
+	}
+	return false
+}
+
+type csgHit struct {
+	hit  Hit
+	left bool
+}
+
+// filterHits walks the sorted hits of both operands, toggling which of them
+// the ray is inside of, and keeps the hits csgAllowed accepts
+func (c CSG) filterHits(hits []csgHit) []Hit {
+	in_left := false
+	in_right := false
+	result := []Hit{}
+	for _, h := range hits {
+		if csgAllowed(c.Op, h.left, in_left, in_right) {
+			result = append(result, h.hit)
+		}
+		if h.left {
+			in_left = !in_left
+		} else {
+			in_right = !in_right
+		}
+	}
+	return result
+}
+
+func (c CSG) IntersectRay(r Ray) []Hit {
+	ray := r.Transform(c.Xf.Inverse())
+
+	hits := []csgHit{}
+	for _, h := range c.Left.IntersectRay(ray) {
+		hits = append(hits, csgHit{NewChildHit(c.Left, h), true})
+	}
+	for _, h := range c.Right.IntersectRay(ray) {
+		hits = append(hits, csgHit{NewChildHit(c.Right, h), false})
+	}
+	sort.SliceStable(hits, func(i, j int) bool {
+		return hits[i].hit.T < hits[j].hit.T
+	})
+
+	return c.filterHits(hits)
+}
+
+func (c CSG) NormalAt(world_point nmath.Vec3, hit Hit) nmath.Vec3 {
+	return childNormalAt(c.Xf, world_point, hit)
+}
+
+func (c CSG) TangentAt(world_point nmath.Vec3, hit Hit) nmath.Vec3 {
+	return childTangentAt(c.Xf, world_point, hit)
+}
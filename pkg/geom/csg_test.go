@@ -0,0 +1,84 @@
+package geom_test
+
+import (
+	. "github.com/novelalex/soft-raytracer/pkg/geom"
+	nm "github.com/novelalex/soft-raytracer/pkg/nmath"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CSG", func() {
+	Describe("IntersectRay", func() {
+		It("misses when the ray misses both shapes", func() {
+			s := DefaultSphere()
+			c := DefaultCube()
+			csg := NewCSG(CSGUnion, &s, &c)
+			xs := csg.IntersectRay(NewRay(nm.NewVec3(0, 2, -5), nm.NewVec3(0, 0, 1)))
+			Expect(xs).To(BeEmpty())
+		})
+
+		It("keeps only the hits on the surface of the result", func() {
+			s := DefaultSphere()
+			c := DefaultCube()
+			c.Translate(0, 0, 0.5)
+			r := NewRay(nm.NewVec3(0, 0, -5), nm.NewVec3(0, 0, 1))
+
+			cases := []struct {
+				op       CSGOperation
+				t0, t1   float64
+				s0, s1   Shape
+				expected int
+			}{
+				{CSGUnion, 4, 6.5, &s, &c, 2},
+				{CSGIntersection, 4.5, 6, &c, &s, 2},
+				{CSGDifference, 4, 4.5, &s, &c, 2},
+			}
+
+			for _, tc := range cases {
+				csg := NewCSG(tc.op, &s, &c)
+				xs := csg.IntersectRay(r)
+				Expect(xs).To(HaveLen(tc.expected))
+				Expect(nm.ApproxEq(xs[0].T, tc.t0)).To(BeTrue())
+				Expect(nm.ApproxEq(xs[1].T, tc.t1)).To(BeTrue())
+				Expect(xs[0].Shape).To(BeIdenticalTo(tc.s0))
+				Expect(xs[1].Shape).To(BeIdenticalTo(tc.s1))
+			}
+		})
+
+		It("treats a group operand as one solid", func() {
+			s1 := DefaultSphere()
+			s1.Translate(0, 0, -1)
+			s2 := DefaultSphere()
+			s2.Translate(0, 0, 1)
+			g := NewGroup()
+			g.AddChild(&s1, &s2)
+			c := DefaultCube()
+			c.Scale(3, 3, 0.5)
+
+			csg := NewCSG(CSGDifference, &g, &c)
+			xs := csg.IntersectRay(NewRay(nm.NewVec3(0, 0, -5), nm.NewVec3(0, 0, 1)))
+
+			Expect(xs).To(HaveLen(4))
+			Expect(nm.ApproxEq(xs[0].T, 3)).To(BeTrue())
+			Expect(nm.ApproxEq(xs[1].T, 4.5)).To(BeTrue())
+			Expect(nm.ApproxEq(xs[2].T, 5.5)).To(BeTrue())
+			Expect(nm.ApproxEq(xs[3].T, 7)).To(BeTrue())
+		})
+	})
+
+	Describe("NormalAt", func() {
+		It("asks the operand that was hit", func() {
+			s := DefaultSphere()
+			c := DefaultCube()
+			c.Translate(0, 0, 0.5)
+			csg := NewCSG(CSGDifference, &s, &c)
+			csg.Translate(0, 1, 0)
+
+			xs := csg.IntersectRay(NewRay(nm.NewVec3(0, 1, -5), nm.NewVec3(0, 0, 1)))
+			Expect(xs).To(HaveLen(2))
+
+			n := csg.NormalAt(nm.NewVec3(0, 1, -0.5), xs[1])
+			Expect(n.ApproxEq(nm.NewVec3(0, 0, -1))).To(BeTrue())
+		})
+	})
+})
@@ -7,27 +7,27 @@ import (
 )
 
 type Cube struct {
-	Xf nmath.Mat4
+	Xf nmath.Transform
 }
 
 func DefaultCube() Cube {
 
 	return Cube{
-		nmath.Mat4Identity(),
+		nmath.IdentityTransform(),
 	}
 }
 func NewCube(t nmath.Mat4) Cube {
 	return Cube{
-		t,
+		nmath.NewTransform(t),
 	}
 }
 
-func (c Cube) Transform() nmath.Mat4 {
+func (c Cube) Transform() nmath.Transform {
 	return c.Xf
 }
 
 func (c *Cube) SetTransform(m nmath.Mat4) {
-	c.Xf = m
+	c.Xf = nmath.NewTransform(m)
 }
 
 func (c *Cube) Translate(x, y, z float64) *Cube {
@@ -71,18 +71,37 @@ func (c Cube) localNormalAt(p nmath.Vec3) nmath.Vec3 {
 
 }
 
-func (c Cube) NormalAt(world_point nmath.Vec3) nmath.Vec3 {
+func (c Cube) NormalAt(world_point nmath.Vec3, hit Hit) nmath.Vec3 {
 	object_point := c.Xf.Inverse().MultV(world_point.AsPoint4())
 	object_normal := c.localNormalAt(object_point.DropW())
-	world_normal := c.Xf.Inverse().Transpose().MultV(object_normal.AsVector4())
+	world_normal := c.Xf.InverseTranspose().MultV(object_normal.AsVector4())
 	return world_normal.DropW().Normalize()
 }
 
-func axisAlignedBoundingBoxCheckAxis(origin, direction float64) (float64, float64) {
+// localTangentAt follows the faces of CubeMapping
+func (c Cube) localTangentAt(p nmath.Vec3) nmath.Vec3 {
+	n := c.localNormalAt(p)
+	switch {
+	case n.X > 0:
+		return nmath.NewVec3(0, 0, -1)
+	case n.X < 0:
+		return nmath.NewVec3(0, 0, 1)
+	case n.Z < 0:
+		return nmath.NewVec3(-1, 0, 0)
+	}
+	return nmath.NewVec3(1, 0, 0)
+}
+
+func (c Cube) TangentAt(world_point nmath.Vec3, hit Hit) nmath.Vec3 {
+	object_point := c.Xf.Inverse().MultV(world_point.AsPoint4())
+	return worldTangent(c.Xf, c.localTangentAt(object_point.DropW()))
+}
+
+func axisAlignedBoundingBoxCheckAxis(origin, direction, minimum, maximum float64) (float64, float64) {
 	var tmin float64
 	var tmax float64
-	tmin_numerator := (-1 - origin)
-	tmax_numerator := (1 - origin)
+	tmin_numerator := (minimum - origin)
+	tmax_numerator := (maximum - origin)
 
 	if math.Abs(direction) >= nmath.F64Epsilon {
 		tmin = tmin_numerator / direction
@@ -99,9 +118,9 @@ func axisAlignedBoundingBoxCheckAxis(origin, direction float64) (float64, float6
 }
 
 func (c Cube) localIntersectRay(r Ray) []float64 {
-	xtmin, xtmax := axisAlignedBoundingBoxCheckAxis(r.Origin.X, r.Dir.X)
-	ytmin, ytmax := axisAlignedBoundingBoxCheckAxis(r.Origin.Y, r.Dir.Y)
-	ztmin, ztmax := axisAlignedBoundingBoxCheckAxis(r.Origin.Z, r.Dir.Z)
+	xtmin, xtmax := axisAlignedBoundingBoxCheckAxis(r.Origin.X, r.Dir.X, -1, 1)
+	ytmin, ytmax := axisAlignedBoundingBoxCheckAxis(r.Origin.Y, r.Dir.Y, -1, 1)
+	ztmin, ztmax := axisAlignedBoundingBoxCheckAxis(r.Origin.Z, r.Dir.Z, -1, 1)
 
 	tmin := max(xtmin, ytmin, ztmin)
 	tmax := min(xtmax, ytmax, ztmax)
@@ -113,7 +132,11 @@ func (c Cube) localIntersectRay(r Ray) []float64 {
 	return []float64{tmin, tmax}
 }
 
-func (c Cube) IntersectRay(r Ray) []float64 {
+func (c Cube) Bounds() AABB {
+	return unitAABB().Transform(c.Xf.Matrix())
+}
+
+func (c Cube) IntersectRay(r Ray) []Hit {
 	ray := r.Transform(c.Xf.Inverse())
-	return c.localIntersectRay(ray)
+	return newHits(c.localIntersectRay(ray))
 }
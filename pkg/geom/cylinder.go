@@ -0,0 +1,174 @@
+package geom
+
+import (
+	"math"
+
+	"github.com/novelalex/soft-raytracer/pkg/nmath"
+)
+
+// Cylinder is a unit radius cylinder around the y axis, truncated to
+// Minimum < y < Maximum (exclusive) and capped at both ends if Closed
+type Cylinder struct {
+	Xf      nmath.Transform
+	Minimum float64
+	Maximum float64
+	Closed  bool
+}
+
+func DefaultCylinder() Cylinder {
+	return Cylinder{
+		nmath.IdentityTransform(),
+		math.Inf(-1),
+		math.Inf(1),
+		false,
+	}
+}
+
+func NewCylinder(t nmath.Mat4) Cylinder {
+	return Cylinder{
+		nmath.NewTransform(t),
+		math.Inf(-1),
+		math.Inf(1),
+		false,
+	}
+}
+
+func NewTruncatedCylinder(minimum, maximum float64, closed bool) Cylinder {
+	return Cylinder{
+		nmath.IdentityTransform(),
+		minimum,
+		maximum,
+		closed,
+	}
+}
+
+func (c Cylinder) Transform() nmath.Transform {
+	return c.Xf
+}
+
+func (c *Cylinder) SetTransform(m nmath.Mat4) {
+	c.Xf = nmath.NewTransform(m)
+}
+
+func (c *Cylinder) Translate(x, y, z float64) *Cylinder {
+	c.SetTransform(c.Xf.Mult(nmath.NewTranslation(x, y, z)))
+	return c
+}
+
+func (c *Cylinder) Scale(x, y, z float64) *Cylinder {
+	c.SetTransform(c.Xf.Mult(nmath.NewScaling(x, y, z)))
+	return c
+}
+
+func (c *Cylinder) Rotate(angle float64, axis nmath.Vec3) *Cylinder {
+	c.SetTransform(c.Xf.Mult(nmath.NewRotation(angle, axis)))
+	return c
+}
+
+func (c *Cylinder) RotateX(angle float64) *Cylinder {
+	c.SetTransform(c.Xf.Mult(nmath.NewRotationX(angle)))
+	return c
+}
+
+func (c *Cylinder) RotateY(angle float64) *Cylinder {
+	c.SetTransform(c.Xf.Mult(nmath.NewRotationY(angle)))
+	return c
+}
+
+func (c *Cylinder) RotateZ(angle float64) *Cylinder {
+	c.SetTransform(c.Xf.Mult(nmath.NewRotationZ(angle)))
+	return c
+}
+
+func (c Cylinder) localNormalAt(p nmath.Vec3) nmath.Vec3 {
+	dist := p.X*p.X + p.Z*p.Z
+	if dist < 1 && p.Y >= c.Maximum-nmath.F64Epsilon {
+		return nmath.NewVec3(0, 1, 0)
+	} else if dist < 1 && p.Y <= c.Minimum+nmath.F64Epsilon {
+		return nmath.NewVec3(0, -1, 0)
+	}
+	return nmath.NewVec3(p.X, 0, p.Z)
+}
+
+func (c Cylinder) NormalAt(world_point nmath.Vec3, hit Hit) nmath.Vec3 {
+	object_point := c.Xf.Inverse().MultV(world_point.AsPoint4())
+	object_normal := c.localNormalAt(object_point.DropW())
+	world_normal := c.Xf.InverseTranspose().MultV(object_normal.AsVector4())
+	return world_normal.DropW().Normalize()
+}
+
+// TangentAt goes around the sides and along x on the caps
+func (c Cylinder) TangentAt(world_point nmath.Vec3, hit Hit) nmath.Vec3 {
+	object_point := c.Xf.Inverse().MultV(world_point.AsPoint4()).DropW()
+	if c.localNormalAt(object_point).Y != 0 {
+		return worldTangent(c.Xf, nmath.NewVec3(1, 0, 0))
+	}
+	return worldTangent(c.Xf, tangentAroundY(object_point))
+}
+
+func (c Cylinder) Bounds() AABB {
+	local := NewAABB(nmath.NewVec3(-1, c.Minimum, -1), nmath.NewVec3(1, c.Maximum, 1))
+	return local.Transform(c.Xf.Matrix())
+}
+
+// checkCap reports whether the point at t is within the unit radius of the cap
+func checkCap(r Ray, t, radius float64) bool {
+	x := r.Origin.X + t*r.Dir.X
+	z := r.Origin.Z + t*r.Dir.Z
+	return x*x+z*z <= radius*radius+nmath.F64Epsilon
+}
+
+func (c Cylinder) intersectCaps(r Ray, xs []float64) []float64 {
+	if !c.Closed || math.Abs(r.Dir.Y) < nmath.F64Epsilon {
+		return xs
+	}
+
+	t := (c.Minimum - r.Origin.Y) / r.Dir.Y
+	if checkCap(r, t, 1) {
+		xs = append(xs, t)
+	}
+
+	t = (c.Maximum - r.Origin.Y) / r.Dir.Y
+	if checkCap(r, t, 1) {
+		xs = append(xs, t)
+	}
+	return xs
+}
+
+func (c Cylinder) localIntersectRay(r Ray) []float64 {
+	xs := []float64{}
+
+	a := r.Dir.X*r.Dir.X + r.Dir.Z*r.Dir.Z
+
+	// parallel to the y axis, can only hit the caps
+	if math.Abs(a) >= nmath.F64Epsilon {
+		b := 2*r.Origin.X*r.Dir.X + 2*r.Origin.Z*r.Dir.Z
+		c0 := r.Origin.X*r.Origin.X + r.Origin.Z*r.Origin.Z - 1
+
+		sol := nmath.Solve(a, b, c0)
+		for _, t := range sol {
+			y := r.Origin.Y + t*r.Dir.Y
+			if c.Minimum < y && y < c.Maximum {
+				xs = append(xs, t)
+			}
+		}
+	}
+
+	xs = c.intersectCaps(r, xs)
+	sortHits(xs)
+	return xs
+}
+
+func (c Cylinder) IntersectRay(r Ray) []Hit {
+	ray := r.Transform(c.Xf.Inverse())
+	return newHits(c.localIntersectRay(ray))
+}
+
+// sortHits sorts the handful of t values a primitive returns
+func sortHits(xs []float64) {
+	for i := 1; i < len(xs); i++ {
+		for j := i; j > 0 && xs[j] < xs[j-1]; j-- {
+			xs[j], xs[j-1] = xs[j-1], xs[j]
+		}
+	}
+}
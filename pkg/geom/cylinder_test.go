@@ -0,0 +1,132 @@
+package geom_test
+
+import (
+	"math"
+
+	. "github.com/novelalex/soft-raytracer/pkg/geom"
+	nm "github.com/novelalex/soft-raytracer/pkg/nmath"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Cylinder", func() {
+	Describe("IntersectRay", func() {
+		It("misses rays that don't reach the surface", func() {
+			cases := []struct {
+				origin nm.Vec3
+				dir    nm.Vec3
+			}{
+				{nm.NewVec3(1, 0, 0), nm.NewVec3(0, 1, 0)},
+				{nm.NewVec3(0, 0, 0), nm.NewVec3(0, 1, 0)},
+				{nm.NewVec3(0, 0, -5), nm.NewVec3(1, 1, 1)},
+			}
+
+			c := DefaultCylinder()
+			for _, tc := range cases {
+				xs := c.IntersectRay(NewRay(tc.origin, tc.dir.Normalize()))
+				Expect(xs).To(BeEmpty())
+			}
+		})
+
+		It("hits the curved surface", func() {
+			cases := []struct {
+				origin nm.Vec3
+				dir    nm.Vec3
+				t0, t1 float64
+			}{
+				{nm.NewVec3(1, 0, -5), nm.NewVec3(0, 0, 1), 5, 5},
+				{nm.NewVec3(0, 0, -5), nm.NewVec3(0, 0, 1), 4, 6},
+				{nm.NewVec3(0.5, 0, -5), nm.NewVec3(0.1, 1, 1), 6.80798, 7.08872},
+			}
+
+			c := DefaultCylinder()
+			for _, tc := range cases {
+				xs := c.IntersectRay(NewRay(tc.origin, tc.dir.Normalize()))
+				Expect(xs).NotTo(BeEmpty())
+				Expect(nm.LooseEq(xs[0].T, tc.t0)).To(BeTrue())
+				Expect(nm.LooseEq(xs[len(xs)-1].T, tc.t1)).To(BeTrue())
+			}
+		})
+
+		It("respects truncation", func() {
+			cases := []struct {
+				origin nm.Vec3
+				dir    nm.Vec3
+				count  int
+			}{
+				{nm.NewVec3(0, 1.5, 0), nm.NewVec3(0.1, 1, 0), 0},
+				{nm.NewVec3(0, 3, -5), nm.NewVec3(0, 0, 1), 0},
+				{nm.NewVec3(0, 0, -5), nm.NewVec3(0, 0, 1), 0},
+				{nm.NewVec3(0, 2, -5), nm.NewVec3(0, 0, 1), 0},
+				{nm.NewVec3(0, 1, -5), nm.NewVec3(0, 0, 1), 0},
+				{nm.NewVec3(0, 1.5, -2), nm.NewVec3(0, 0, 1), 2},
+			}
+
+			c := NewTruncatedCylinder(1, 2, false)
+			for _, tc := range cases {
+				xs := c.IntersectRay(NewRay(tc.origin, tc.dir.Normalize()))
+				Expect(xs).To(HaveLen(tc.count))
+			}
+		})
+
+		It("hits the end caps of closed cylinders", func() {
+			cases := []struct {
+				origin nm.Vec3
+				dir    nm.Vec3
+				count  int
+			}{
+				{nm.NewVec3(0, 3, 0), nm.NewVec3(0, -1, 0), 2},
+				{nm.NewVec3(0, 3, -2), nm.NewVec3(0, -1, 2), 2},
+				{nm.NewVec3(0, 4, -2), nm.NewVec3(0, -1, 1), 2},
+				{nm.NewVec3(0, 0, -2), nm.NewVec3(0, 1, 2), 2},
+				{nm.NewVec3(0, -1, -2), nm.NewVec3(0, 1, 1), 2},
+			}
+
+			c := NewTruncatedCylinder(1, 2, true)
+			for _, tc := range cases {
+				xs := c.IntersectRay(NewRay(tc.origin, tc.dir.Normalize()))
+				Expect(xs).To(HaveLen(tc.count))
+			}
+		})
+	})
+
+	Describe("NormalAt", func() {
+		It("points away from the axis and out of the caps", func() {
+			cases := []struct {
+				point  nm.Vec3
+				normal nm.Vec3
+			}{
+				{nm.NewVec3(1, 0, 0), nm.NewVec3(1, 0, 0)},
+				{nm.NewVec3(0, 5, -1), nm.NewVec3(0, 0, -1)},
+				{nm.NewVec3(-1, 1, 0), nm.NewVec3(-1, 0, 0)},
+				{nm.NewVec3(0.5, 1, 0), nm.NewVec3(0, -1, 0)},
+				{nm.NewVec3(0, 2, 0.5), nm.NewVec3(0, 1, 0)},
+			}
+
+			c := DefaultCylinder()
+			capped := NewTruncatedCylinder(1, 2, true)
+			for i, tc := range cases {
+				shape := c
+				if i >= 3 {
+					shape = capped
+				}
+				Expect(shape.NormalAt(tc.point, NewHit(0)).ApproxEq(tc.normal)).To(BeTrue())
+			}
+		})
+	})
+
+	Describe("Bounds", func() {
+		It("is infinite when untruncated", func() {
+			c := DefaultCylinder()
+			Expect(c.Bounds().IsInfinite()).To(BeTrue())
+		})
+
+		It("follows the truncation", func() {
+			c := NewTruncatedCylinder(-5, 3, false)
+			b := c.Bounds()
+			Expect(b.Min.ApproxEq(nm.NewVec3(-1, -5, -1))).To(BeTrue())
+			Expect(b.Max.ApproxEq(nm.NewVec3(1, 3, 1))).To(BeTrue())
+			Expect(math.IsInf(b.Max.Y, 0)).To(BeFalse())
+		})
+	})
+})
@@ -5,8 +5,31 @@ import (
 )
 
 type Shape interface {
-	Transform() nmath.Mat4
+	Transform() nmath.Transform
 	SetTransform(nmath.Mat4)
-	IntersectRay(Ray) []float64
-	NormalAt(world_point nmath.Vec3) nmath.Vec3
+	IntersectRay(Ray) []Hit
+	// NormalAt is given the Hit that produced world_point for shapes
+	// that interpolate their normal across the surface
+	NormalAt(world_point nmath.Vec3, hit Hit) nmath.Vec3
+	// TangentAt is a direction across the surface at world_point, the one
+	// the shape's usual UV mapping increases u in. Bump and normal maps are
+	// oriented by it.
+	TangentAt(world_point nmath.Vec3, hit Hit) nmath.Vec3
+	// Bounds returns the shape's bounding box after its transform is applied
+	Bounds() AABB
+}
+
+// worldTangent moves a tangent from the object space of a shape with transform xf
+// to world space, tangents follow the surface so they use the transform itself
+func worldTangent(xf nmath.Transform, object_tangent nmath.Vec3) nmath.Vec3 {
+	return xf.Matrix().MultV(object_tangent.AsVector4()).DropW().Normalize()
+}
+
+// tangentAroundY is the tangent of surfaces around the y axis,
+// the way SphericalMapping and CylindricalMapping increase u
+func tangentAroundY(p nmath.Vec3) nmath.Vec3 {
+	if p.X == 0 && p.Z == 0 {
+		return nmath.NewVec3(1, 0, 0)
+	}
+	return nmath.NewVec3(-p.Z, 0, p.X).Normalize()
 }
@@ -0,0 +1,204 @@
+package geom
+
+import (
+	"log"
+	"sort"
+
+	"github.com/novelalex/soft-raytracer/pkg/nmath"
+)
+
+// Group is a shape made of child shapes. The group's transform is applied
+// on top of the children's own transforms, so groups can be nested to build
+// a hierarchy that moves as one.
+//
+// The group keeps the bounds of its children so rays that miss them all
+// can skip the children. Call UpdateBounds after modifying a child that is
+// already in the group.
+type Group struct {
+	Xf       nmath.Transform
+	Children []Shape
+	bounds   AABB
+}
+
+func NewGroup() Group {
+	return Group{
+		nmath.IdentityTransform(),
+		[]Shape{},
+		EmptyAABB(),
+	}
+}
+
+func (g Group) Transform() nmath.Transform {
+	return g.Xf
+}
+
+func (g *Group) SetTransform(m nmath.Mat4) {
+	g.Xf = nmath.NewTransform(m)
+}
+
+func (g *Group) Translate(x, y, z float64) *Group {
+	g.SetTransform(g.Xf.Mult(nmath.NewTranslation(x, y, z)))
+	return g
+}
+
+func (g *Group) Scale(x, y, z float64) *Group {
+	g.SetTransform(g.Xf.Mult(nmath.NewScaling(x, y, z)))
+	return g
+}
+
+func (g *Group) Rotate(angle float64, axis nmath.Vec3) *Group {
+	g.SetTransform(g.Xf.Mult(nmath.NewRotation(angle, axis)))
+	return g
+}
+
+func (g *Group) RotateX(angle float64) *Group {
+	g.SetTransform(g.Xf.Mult(nmath.NewRotationX(angle)))
+	return g
+}
+
+func (g *Group) RotateY(angle float64) *Group {
+	g.SetTransform(g.Xf.Mult(nmath.NewRotationY(angle)))
+	return g
+}
+
+func (g *Group) RotateZ(angle float64) *Group {
+	g.SetTransform(g.Xf.Mult(nmath.NewRotationZ(angle)))
+	return g
+}
+
+func (g *Group) AddChild(children ...Shape) *Group {
+	for _, c := range children {
+		if len(g.Children) == 0 {
+			// the bounds of a zero value Group are a point, not an empty box
+			g.bounds = EmptyAABB()
+		}
+		g.Children = append(g.Children, c)
+		g.bounds = g.bounds.Union(c.Bounds())
+	}
+	return g
+}
+
+// UpdateBounds recomputes the bounds of this group and every group below it
+func (g *Group) UpdateBounds() {
+	g.bounds = EmptyAABB()
+	for _, c := range g.Children {
+		if child, ok := c.(*Group); ok {
+			child.UpdateBounds()
+		}
+		g.bounds = g.bounds.Union(c.Bounds())
+	}
+}
+
+// LocalBounds is the bounds of the children before the group's transform is applied
+func (g Group) LocalBounds() AABB {
+	if len(g.Children) == 0 {
+		return EmptyAABB()
+	}
+	return g.bounds
+}
+
+func (g Group) Bounds() AABB {
+	return g.LocalBounds().Transform(g.Xf.Matrix())
+}
+
+func (g Group) IntersectRay(r Ray) []Hit {
+	ray := r.Transform(g.Xf.Inverse())
+	if _, _, ok := g.LocalBounds().IntersectRay(ray); !ok {
+		return []Hit{}
+	}
+
+	hits := []Hit{}
+	for _, c := range g.Children {
+		for _, h := range c.IntersectRay(ray) {
+			hits = append(hits, NewChildHit(c, h))
+		}
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		return hits[i].T < hits[j].T
+	})
+	return hits
+}
+
+// NormalAt asks the child recorded in hit for the normal, the hit has to
+// come from this group's IntersectRay
+func (g Group) NormalAt(world_point nmath.Vec3, hit Hit) nmath.Vec3 {
+	return childNormalAt(g.Xf, world_point, hit)
+}
+
+// childNormalAt moves world_point into the space of a composite shape with
+// transform xf, asks the child that was hit for its normal, and moves it back
+func childNormalAt(xf nmath.Transform, world_point nmath.Vec3, hit Hit) nmath.Vec3 {
+	if hit.Shape == nil || hit.Inner == nil {
+		log.Panic("NormalAt was given a hit that didn't come from a child")
+	}
+	object_point := xf.Inverse().MultV(world_point.AsPoint4()).DropW()
+	object_normal := hit.Shape.NormalAt(object_point, *hit.Inner)
+	world_normal := xf.InverseTranspose().MultV(object_normal.AsVector4())
+	return world_normal.DropW().Normalize()
+}
+
+func (g Group) TangentAt(world_point nmath.Vec3, hit Hit) nmath.Vec3 {
+	return childTangentAt(g.Xf, world_point, hit)
+}
+
+// childTangentAt is childNormalAt for tangents
+func childTangentAt(xf nmath.Transform, world_point nmath.Vec3, hit Hit) nmath.Vec3 {
+	if hit.Shape == nil || hit.Inner == nil {
+		log.Panic("TangentAt was given a hit that didn't come from a child")
+	}
+	object_point := xf.Inverse().MultV(world_point.AsPoint4()).DropW()
+	return worldTangent(xf, hit.Shape.TangentAt(object_point, *hit.Inner))
+}
+
+// Divide splits groups with at least threshold children into two sub groups
+// along the longest axis of their bounds, recursively, so large groups like
+// meshes can skip most of their children
+func (g *Group) Divide(threshold int) {
+	if threshold <= len(g.Children) && !g.bounds.IsInfinite() {
+		g.splitChildren()
+	}
+
+	for _, c := range g.Children {
+		if child, ok := c.(*Group); ok {
+			child.Divide(threshold)
+		}
+	}
+}
+
+func (g *Group) splitChildren() {
+	left, right, rest := g.partitionChildren()
+	if len(left) == len(g.Children) || len(right) == len(g.Children) {
+		// the children are all stacked on top of each other, splitting won't help
+		return
+	}
+
+	g.Children = rest
+	if len(left) > 0 {
+		sub := NewGroup()
+		sub.AddChild(left...)
+		g.Children = append(g.Children, &sub)
+	}
+	if len(right) > 0 {
+		sub := NewGroup()
+		sub.AddChild(right...)
+		g.Children = append(g.Children, &sub)
+	}
+}
+
+// partitionChildren sorts children into those fully inside either half
+// of the group's bounds and those that straddle the split
+func (g *Group) partitionChildren() ([]Shape, []Shape, []Shape) {
+	left_box, right_box := g.bounds.split()
+	left, right, rest := []Shape{}, []Shape{}, []Shape{}
+	for _, c := range g.Children {
+		b := c.Bounds()
+		if left_box.containsBox(b) {
+			left = append(left, c)
+		} else if right_box.containsBox(b) {
+			right = append(right, c)
+		} else {
+			rest = append(rest, c)
+		}
+	}
+	return left, right, rest
+}
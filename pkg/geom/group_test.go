@@ -0,0 +1,160 @@
+package geom_test
+
+import (
+	"math"
+
+	. "github.com/novelalex/soft-raytracer/pkg/geom"
+	nm "github.com/novelalex/soft-raytracer/pkg/nmath"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Group", func() {
+	Describe("IntersectRay", func() {
+		It("misses when empty", func() {
+			g := NewGroup()
+			xs := g.IntersectRay(NewRay(nm.NewVec3(0, 0, 0), nm.NewVec3(0, 0, 1)))
+			Expect(xs).To(BeEmpty())
+		})
+
+		It("returns the sorted hits of its children", func() {
+			s1 := DefaultSphere()
+			s2 := DefaultSphere()
+			s2.Translate(0, 0, -3)
+			s3 := DefaultSphere()
+			s3.Translate(5, 0, 0)
+			g := NewGroup()
+			g.AddChild(&s1, &s2, &s3)
+
+			xs := g.IntersectRay(NewRay(nm.NewVec3(0, 0, -5), nm.NewVec3(0, 0, 1)))
+
+			Expect(xs).To(HaveLen(4))
+			Expect(xs[0].Shape).To(BeIdenticalTo(&s2))
+			Expect(xs[1].Shape).To(BeIdenticalTo(&s2))
+			Expect(xs[2].Shape).To(BeIdenticalTo(&s1))
+			Expect(xs[3].Shape).To(BeIdenticalTo(&s1))
+		})
+
+		It("applies its transform on top of the child's", func() {
+			s := DefaultSphere()
+			s.Translate(5, 0, 0)
+			g := NewGroup()
+			g.Scale(2, 2, 2)
+			g.AddChild(&s)
+
+			xs := g.IntersectRay(NewRay(nm.NewVec3(10, 0, -10), nm.NewVec3(0, 0, 1)))
+
+			Expect(xs).To(HaveLen(2))
+		})
+
+		It("skips its children when the ray misses its bounds", func() {
+			s := DefaultSphere()
+			g := NewGroup()
+			g.AddChild(&s)
+
+			xs := g.IntersectRay(NewRay(nm.NewVec3(5, 5, -5), nm.NewVec3(0, 0, 1)))
+
+			Expect(xs).To(BeEmpty())
+		})
+	})
+
+	Describe("NormalAt", func() {
+		It("composes the transforms of nested groups", func() {
+			g1 := NewGroup()
+			g1.RotateY(math.Pi / 2)
+			g2 := NewGroup()
+			g2.Scale(1, 2, 3)
+			s := DefaultSphere()
+			s.Translate(5, 0, 0)
+			g2.AddChild(&s)
+			g1.AddChild(&g2)
+
+			point := nm.NewVec3(1.7321, 1.1547, -5.5774)
+			hit := NewChildHit(&g2, NewChildHit(&s, NewHit(0)))
+
+			n := g1.NormalAt(point, hit)
+			Expect(nm.LooseEq(n.X, 0.2857)).To(BeTrue())
+			Expect(nm.LooseEq(n.Y, 0.4286)).To(BeTrue())
+			Expect(nm.LooseEq(n.Z, -0.8571)).To(BeTrue())
+		})
+	})
+
+	Describe("Bounds", func() {
+		It("encloses its transformed children", func() {
+			s := DefaultSphere()
+			s.Translate(2, 5, -3).Scale(2, 2, 2)
+			c := NewTruncatedCylinder(-2, 2, false)
+			c.Translate(-4, -1, 4).Scale(0.5, 1, 0.5)
+			g := NewGroup()
+			g.AddChild(&s, &c)
+
+			b := g.Bounds()
+
+			Expect(b.Min.ApproxEq(nm.NewVec3(-4.5, -3, -5))).To(BeTrue())
+			Expect(b.Max.ApproxEq(nm.NewVec3(4, 7, 4.5))).To(BeTrue())
+		})
+
+		It("is empty without children, even for the zero value", func() {
+			g := NewGroup()
+			Expect(g.Bounds().IsEmpty()).To(BeTrue())
+			Expect(Group{}.Bounds().IsEmpty()).To(BeTrue())
+			Expect(Group{}.LocalBounds().IsEmpty()).To(BeTrue())
+		})
+
+		It("doesn't grow a zero value group to the origin", func() {
+			s := DefaultSphere()
+			s.Translate(5, 5, 5)
+			g := Group{Xf: nm.IdentityTransform()}
+			g.AddChild(&s)
+
+			Expect(g.Bounds().Min.ApproxEq(nm.NewVec3(4, 4, 4))).To(BeTrue())
+			Expect(g.Bounds().Max.ApproxEq(nm.NewVec3(6, 6, 6))).To(BeTrue())
+		})
+
+		It("doesn't make an empty child grow its parent", func() {
+			s := DefaultSphere()
+			s.Translate(5, 5, 5)
+			empty := Group{Xf: nm.IdentityTransform()}
+			g := NewGroup()
+			g.AddChild(&s, &empty)
+
+			Expect(g.Bounds().Min.ApproxEq(nm.NewVec3(4, 4, 4))).To(BeTrue())
+		})
+	})
+
+	Describe("Divide", func() {
+		It("partitions its children into sub groups", func() {
+			s1 := DefaultSphere()
+			s1.Translate(-2, -2, 0)
+			s2 := DefaultSphere()
+			s2.Translate(-2, 2, 0)
+			s3 := DefaultSphere()
+			s3.Scale(4, 4, 4)
+			g := NewGroup()
+			g.AddChild(&s1, &s2, &s3)
+
+			g.Divide(1)
+
+			Expect(g.Children).To(HaveLen(2))
+			Expect(g.Children[0]).To(BeIdenticalTo(&s3))
+			sub := g.Children[1].(*Group)
+			Expect(sub.Children).To(HaveLen(2))
+		})
+
+		It("still hits the same children", func() {
+			g := NewGroup()
+			for i := range 20 {
+				s := DefaultSphere()
+				s.Translate(float64(i)*3, 0, 0)
+				g.AddChild(&s)
+			}
+			before := len(g.IntersectRay(NewRay(nm.NewVec3(-5, 0, 0), nm.NewVec3(1, 0, 0))))
+
+			g.Divide(4)
+
+			after := len(g.IntersectRay(NewRay(nm.NewVec3(-5, 0, 0), nm.NewVec3(1, 0, 0))))
+			Expect(after).To(Equal(before))
+			Expect(after).To(Equal(40))
+		})
+	})
+})
@@ -0,0 +1,43 @@
+package geom
+
+// Hit is a point where a ray crosses a shape's surface.
+// U and V are the surface coordinates of the hit for shapes that need them
+// to compute a normal, like the barycentric coordinates of a SmoothTriangle.
+type Hit struct {
+	T float64
+	U float64
+	V float64
+	// Shape and Inner are set by shapes made of other shapes, like Group.
+	// Shape is the child that was hit and Inner is the Hit the child returned.
+	Shape Shape
+	Inner *Hit
+}
+
+func NewHit(t float64) Hit {
+	return Hit{T: t}
+}
+
+func NewHitUV(t, u, v float64) Hit {
+	return Hit{T: t, U: u, V: v}
+}
+
+// NewChildHit wraps a hit returned by a child shape, T U and V are copied
+// from the child's hit so callers don't have to follow Inner to read them
+func NewChildHit(child Shape, inner Hit) Hit {
+	return Hit{
+		T:     inner.T,
+		U:     inner.U,
+		V:     inner.V,
+		Shape: child,
+		Inner: &inner,
+	}
+}
+
+// newHits wraps the t values of shapes that don't use surface coordinates
+func newHits(ts []float64) []Hit {
+	hits := make([]Hit, len(ts))
+	for i, t := range ts {
+		hits[i] = NewHit(t)
+	}
+	return hits
+}
@@ -0,0 +1,110 @@
+package geom
+
+import (
+	"math"
+
+	. "github.com/novelalex/soft-raytracer/pkg/nmath"
+	"github.com/novelalex/soft-raytracer/pkg/noise"
+)
+
+// PerturbPattern moves the point given to Pattern by up to Scale in each axis,
+// with a separate stretch of Noise for each axis, so straight edges wobble
+type PerturbPattern struct {
+	Pattern Pattern
+	Noise   noise.Noise
+	Scale   float64
+	Xf      Transform
+}
+
+func NewPerturbPattern(pattern Pattern, n noise.Noise, scale float64) PerturbPattern {
+	return PerturbPattern{pattern, n, scale, IdentityTransform()}
+}
+
+func (p PerturbPattern) Transform() Transform {
+	return p.Xf
+}
+
+func (p *PerturbPattern) SetTransform(m Mat4) {
+	p.Xf = NewTransform(m)
+}
+
+func (p PerturbPattern) AtObject(obj Shape, point Vec3) Color {
+	return objectPatternAt(p, obj, point)
+}
+
+// offsets to parts of the noise far enough apart to be unrelated
+var (
+	perturbOffsetY = NewVec3(31.416, 47.853, 12.793)
+	perturbOffsetZ = NewVec3(-71.257, 19.341, -53.109)
+)
+
+func (p PerturbPattern) At(point Vec3) Color {
+	offset := NewVec3(
+		p.Noise.At(point),
+		p.Noise.At(point.Add(perturbOffsetY)),
+		p.Noise.At(point.Add(perturbOffsetZ)),
+	)
+	return patternAt(p.Pattern, point.Add(offset.Mult(p.Scale)))
+}
+
+// WoodPattern is rings around the y axis going from A to B once per unit,
+// distorted by Turbulence times Noise
+type WoodPattern struct {
+	A          Pattern
+	B          Pattern
+	Noise      noise.Fractal
+	Turbulence float64
+	Xf         Transform
+}
+
+func NewWoodPattern(a, b Color, seed uint64) WoodPattern {
+	return WoodPattern{NewSolidPattern(a), NewSolidPattern(b), noise.Fractal{Source: noise.NewPerlin(seed)}, 0.2, IdentityTransform()}
+}
+
+func (p WoodPattern) Transform() Transform {
+	return p.Xf
+}
+
+func (p *WoodPattern) SetTransform(m Mat4) {
+	p.Xf = NewTransform(m)
+}
+
+func (p WoodPattern) AtObject(obj Shape, point Vec3) Color {
+	return objectPatternAt(p, obj, point)
+}
+
+func (p WoodPattern) At(point Vec3) Color {
+	d := math.Sqrt(point.X*point.X+point.Z*point.Z) + p.Turbulence*p.Noise.At(point)
+	return lerpColor(patternAt(p.A, point), patternAt(p.B, point), d-math.Floor(d))
+}
+
+// MarblePattern is veins along x, a sine wave from A to B and back every two
+// units, pushed around by Turbulence times the turbulence of Noise
+type MarblePattern struct {
+	A          Pattern
+	B          Pattern
+	Noise      noise.Fractal
+	Turbulence float64
+	Xf         Transform
+}
+
+func NewMarblePattern(a, b Color, seed uint64) MarblePattern {
+	return MarblePattern{NewSolidPattern(a), NewSolidPattern(b), noise.Fractal{Source: noise.NewPerlin(seed), Octaves: 6}, 5, IdentityTransform()}
+}
+
+func (p MarblePattern) Transform() Transform {
+	return p.Xf
+}
+
+func (p *MarblePattern) SetTransform(m Mat4) {
+	p.Xf = NewTransform(m)
+}
+
+func (p MarblePattern) AtObject(obj Shape, point Vec3) Color {
+	return objectPatternAt(p, obj, point)
+}
+
+func (p MarblePattern) At(point Vec3) Color {
+	t := 0.5 + 0.5*math.Sin((point.X+p.Turbulence*p.Noise.Turbulence(point))*math.Pi)
+	return lerpColor(patternAt(p.A, point), patternAt(p.B, point), t)
+}
@@ -0,0 +1,90 @@
+package geom_test
+
+import (
+	. "github.com/novelalex/soft-raytracer/pkg/geom"
+	nm "github.com/novelalex/soft-raytracer/pkg/nmath"
+	"github.com/novelalex/soft-raytracer/pkg/noise"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// constantNoise is the same everywhere, so the tests know how far points move
+type constantNoise float64
+
+func (n constantNoise) At(nm.Vec3) float64 {
+	return float64(n)
+}
+
+var _ = Describe("PerturbPattern", func() {
+	white := nm.NewColor(1, 1, 1)
+	black := nm.NewColor(0, 0, 0)
+
+	It("moves the point before evaluating the wrapped pattern", func() {
+		stripes := NewStripePattern(white, black)
+		p := NewPerturbPattern(&stripes, constantNoise(0.5), 1)
+
+		Expect(stripes.At(nm.NewVec3(0.75, 0, 0))).To(Equal(white))
+		Expect(p.At(nm.NewVec3(0.75, 0, 0))).To(Equal(black))
+		Expect(p.At(nm.NewVec3(0.25, 0, 0))).To(Equal(white))
+	})
+
+	It("applies the transform of the wrapped pattern", func() {
+		stripes := NewStripePattern(white, black)
+		stripes.SetTransform(nm.NewScaling(2, 1, 1))
+		p := NewPerturbPattern(&stripes, constantNoise(0.5), 1)
+
+		Expect(p.At(nm.NewVec3(1.25, 0, 0))).To(Equal(white))
+		Expect(p.At(nm.NewVec3(1.75, 0, 0))).To(Equal(black))
+	})
+
+	It("is the wrapped pattern when the scale is zero", func() {
+		checkers := NewCheckerPattern(white, black)
+		p := NewPerturbPattern(&checkers, noise.NewPerlin(1), 0)
+		for _, point := range []nm.Vec3{nm.NewVec3(0.5, 0.5, 0.5), nm.NewVec3(1.5, 0.5, 0.5), nm.NewVec3(-0.2, 3.1, 7)} {
+			Expect(p.At(point)).To(Equal(checkers.At(point)))
+		}
+	})
+})
+
+var _ = Describe("WoodPattern", func() {
+	a := nm.NewColor(0.6, 0.4, 0.2)
+	b := nm.NewColor(0.3, 0.2, 0.1)
+
+	It("makes rings around the y axis", func() {
+		p := NewWoodPattern(a, b, 1)
+		p.Turbulence = 0
+
+		Expect(p.At(nm.NewVec3(0, 5, 0))).To(Equal(a))
+		Expect(p.At(nm.NewVec3(0, 0, 0.5)).AsVec3().ApproxEq(nm.NewVec3(0.45, 0.3, 0.15))).To(BeTrue())
+		Expect(p.At(nm.NewVec3(0.6, 0, 0.8)).AsVec3().ApproxEq(a.AsVec3())).To(BeTrue())
+	})
+
+	It("is the same for the same seed", func() {
+		p, q := NewWoodPattern(a, b, 3), NewWoodPattern(a, b, 3)
+		r := NewWoodPattern(a, b, 4)
+		point := nm.NewVec3(0.31, 0.72, 1.93)
+		Expect(p.At(point)).To(Equal(q.At(point)))
+		Expect(p.At(point)).NotTo(Equal(r.At(point)))
+	})
+})
+
+var _ = Describe("MarblePattern", func() {
+	a := nm.NewColor(1, 1, 1)
+	b := nm.NewColor(0.1, 0.1, 0.1)
+
+	It("makes veins along x", func() {
+		p := NewMarblePattern(a, b, 1)
+		p.Turbulence = 0
+
+		Expect(p.At(nm.NewVec3(-0.5, 0, 0)).AsVec3().ApproxEq(a.AsVec3())).To(BeTrue())
+		Expect(p.At(nm.NewVec3(0.5, 7, 3)).AsVec3().ApproxEq(b.AsVec3())).To(BeTrue())
+	})
+
+	It("is the same for the same seed", func() {
+		p, q := NewMarblePattern(a, b, 3), NewMarblePattern(a, b, 3)
+		r := NewMarblePattern(a, b, 4)
+		point := nm.NewVec3(0.31, 0.72, 1.93)
+		Expect(p.At(point)).To(Equal(q.At(point)))
+		Expect(p.At(point)).NotTo(Equal(r.At(point)))
+	})
+})
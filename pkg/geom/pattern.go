@@ -9,130 +9,256 @@ import (
 type Pattern interface {
 	At(Vec3) Color
 	AtObject(Shape, Vec3) Color
-	Transform() Mat4
+	Transform() Transform
 	SetTransform(Mat4)
 }
 
+// patternAt evaluates p at a point in the space of the pattern containing it,
+// p's own transform is applied on top
+func patternAt(p Pattern, point Vec3) Color {
+	return p.At(p.Transform().Inverse().MultV(point.AsPoint4()).DropW())
+}
+
+// objectPatternAt evaluates p at a world point on obj, the usual AtObject.
+// It takes the pattern by value so it doesn't escape to the heap on every call.
+func objectPatternAt[P interface {
+	At(Vec3) Color
+	Transform() Transform
+}](p P, obj Shape, point Vec3) Color {
+	object_point := obj.Transform().Inverse().MultV(point.AsPoint4())
+	pattern_point := p.Transform().Inverse().MultV(object_point).DropW()
+	return p.At(pattern_point)
+}
+
+// SolidPattern is the same color everywhere, it is how the patterns that
+// take other patterns are given a plain color
+type SolidPattern struct {
+	C  Color
+	Xf Transform
+}
+
+func NewSolidPattern(c Color) *SolidPattern {
+	return &SolidPattern{c, IdentityTransform()}
+}
+
+func (p SolidPattern) Transform() Transform {
+	return p.Xf
+}
+
+func (p *SolidPattern) SetTransform(m Mat4) {
+	p.Xf = NewTransform(m)
+}
+
+func (p SolidPattern) AtObject(obj Shape, point Vec3) Color {
+	return p.C
+}
+
+func (p SolidPattern) At(point Vec3) Color {
+	return p.C
+}
+
 type StripePattern struct {
-	A  Color
-	B  Color
-	Xf Mat4
+	A  Pattern
+	B  Pattern
+	Xf Transform
 }
 
 func NewStripePattern(a, b Color) StripePattern {
-	return StripePattern{a, b, Mat4Identity()}
+	return StripePattern{NewSolidPattern(a), NewSolidPattern(b), IdentityTransform()}
 }
 
-func (s StripePattern) Transform() Mat4 {
+func (s StripePattern) Transform() Transform {
 	return s.Xf
 }
 
 func (s *StripePattern) SetTransform(m Mat4) {
-	s.Xf = m
+	s.Xf = NewTransform(m)
 }
 
 func (s StripePattern) AtObject(obj Shape, p Vec3) Color {
-	object_point := obj.Transform().Inverse().MultV(p.AsPoint4())
-	pattern_point := s.Transform().Inverse().MultV(object_point).DropW()
-	return s.At(pattern_point)
+	return objectPatternAt(s, obj, p)
 }
 
 func (s StripePattern) At(point Vec3) Color {
 	if ApproxEq(math.Mod(math.Floor(point.X), 2), 0) {
-		return s.A
+		return patternAt(s.A, point)
 	}
-	return s.B
+	return patternAt(s.B, point)
 }
 
 type GradientPattern struct {
-	A  Color
-	B  Color
-	Xf Mat4
+	A  Pattern
+	B  Pattern
+	Xf Transform
 }
 
 func NewGradientPattern(a, b Color) GradientPattern {
-	return GradientPattern{a, b, Mat4Identity()}
+	return GradientPattern{NewSolidPattern(a), NewSolidPattern(b), IdentityTransform()}
 }
 
-func (p GradientPattern) Transform() Mat4 {
+func (p GradientPattern) Transform() Transform {
 	return p.Xf
 }
 
 func (p *GradientPattern) SetTransform(m Mat4) {
-	p.Xf = m
+	p.Xf = NewTransform(m)
 }
 
 func (p GradientPattern) AtObject(obj Shape, point Vec3) Color {
-	object_point := obj.Transform().Inverse().MultV(point.AsPoint4())
-	pattern_point := p.Transform().Inverse().MultV(object_point).DropW()
-	return p.At(pattern_point)
+	return objectPatternAt(p, obj, point)
 }
 
 func (p GradientPattern) At(point Vec3) Color {
-	distance := p.B.AsVec3().Sub(p.A.AsVec3())
+	a := patternAt(p.A, point).AsVec3()
+	b := patternAt(p.B, point).AsVec3()
+	distance := b.Sub(a)
 	fraction := point.X - math.Floor(point.X)
 
-	return p.A.AsVec3().Add(distance.Mult(fraction)).AsColor()
+	return a.Add(distance.Mult(fraction)).AsColor()
 }
 
 type RingPattern struct {
-	A  Color
-	B  Color
-	Xf Mat4
+	A  Pattern
+	B  Pattern
+	Xf Transform
 }
 
 func NewRingPattern(a, b Color) RingPattern {
-	return RingPattern{a, b, Mat4Identity()}
+	return RingPattern{NewSolidPattern(a), NewSolidPattern(b), IdentityTransform()}
 }
 
-func (p RingPattern) Transform() Mat4 {
+func (p RingPattern) Transform() Transform {
 	return p.Xf
 }
 
 func (p *RingPattern) SetTransform(m Mat4) {
-	p.Xf = m
+	p.Xf = NewTransform(m)
 }
 
 func (p RingPattern) AtObject(obj Shape, point Vec3) Color {
-	object_point := obj.Transform().Inverse().MultV(point.AsPoint4())
-	pattern_point := p.Transform().Inverse().MultV(object_point).DropW()
-	return p.At(pattern_point)
+	return objectPatternAt(p, obj, point)
 }
 
 func (p RingPattern) At(point Vec3) Color {
 	if math.Mod(math.Floor(math.Sqrt(point.X*point.X+point.Z*point.Z)), 2.0) == 0 {
-		return p.A
+		return patternAt(p.A, point)
 	}
-	return p.B
+	return patternAt(p.B, point)
 }
 
 type CheckerPattern struct {
-	A  Color
-	B  Color
-	Xf Mat4
+	A  Pattern
+	B  Pattern
+	Xf Transform
 }
 
 func NewCheckerPattern(a, b Color) CheckerPattern {
-	return CheckerPattern{a, b, Mat4Identity()}
+	return CheckerPattern{NewSolidPattern(a), NewSolidPattern(b), IdentityTransform()}
 }
 
-func (p CheckerPattern) Transform() Mat4 {
+func (p CheckerPattern) Transform() Transform {
 	return p.Xf
 }
 
 func (p *CheckerPattern) SetTransform(m Mat4) {
-	p.Xf = m
+	p.Xf = NewTransform(m)
 }
 
 func (p CheckerPattern) AtObject(obj Shape, point Vec3) Color {
-	object_point := obj.Transform().Inverse().MultV(point.AsPoint4())
-	pattern_point := p.Transform().Inverse().MultV(object_point).DropW()
-	return p.At(pattern_point)
+	return objectPatternAt(p, obj, point)
 }
 
 func (p CheckerPattern) At(point Vec3) Color {
 	if math.Mod(math.Floor(point.X)+math.Floor(point.Y)+math.Floor(point.Z), 2.0) == 0 {
-		return p.A
+		return patternAt(p.A, point)
+	}
+	return patternAt(p.B, point)
+}
+
+type BlendMode int
+
+const (
+	BlendAverage BlendMode = iota
+	BlendMultiply
+)
+
+// BlendPattern combines all of Patterns at every point,
+// either averaging their colors or multiplying them together
+type BlendPattern struct {
+	Patterns []Pattern
+	Mode     BlendMode
+	Xf       Transform
+}
+
+func NewBlendPattern(mode BlendMode, patterns ...Pattern) BlendPattern {
+	return BlendPattern{patterns, mode, IdentityTransform()}
+}
+
+func (p BlendPattern) Transform() Transform {
+	return p.Xf
+}
+
+func (p *BlendPattern) SetTransform(m Mat4) {
+	p.Xf = NewTransform(m)
+}
+
+func (p BlendPattern) AtObject(obj Shape, point Vec3) Color {
+	return objectPatternAt(p, obj, point)
+}
+
+func (p BlendPattern) At(point Vec3) Color {
+	if len(p.Patterns) == 0 {
+		return NewColor(0, 0, 0)
+	}
+
+	result := patternAt(p.Patterns[0], point)
+	for _, child := range p.Patterns[1:] {
+		c := patternAt(child, point)
+		if p.Mode == BlendMultiply {
+			result = result.HadamardMult(c)
+		} else {
+			result = result.Add(c)
+		}
+	}
+	if p.Mode == BlendMultiply {
+		return result
+	}
+	return lerpColor(Color{}, result, 1/float64(len(p.Patterns)))
+}
+
+// MaskPattern shows A where Mask is black and B where it is white,
+// grays in between mix the two by the brightness of Mask
+type MaskPattern struct {
+	A    Pattern
+	B    Pattern
+	Mask Pattern
+	Xf   Transform
+}
+
+func NewMaskPattern(a, b, mask Pattern) MaskPattern {
+	return MaskPattern{a, b, mask, IdentityTransform()}
+}
+
+func (p MaskPattern) Transform() Transform {
+	return p.Xf
+}
+
+func (p *MaskPattern) SetTransform(m Mat4) {
+	p.Xf = NewTransform(m)
+}
+
+func (p MaskPattern) AtObject(obj Shape, point Vec3) Color {
+	return objectPatternAt(p, obj, point)
+}
+
+func (p MaskPattern) At(point Vec3) Color {
+	mask := patternAt(p.Mask, point)
+	t := max(0, min((mask.R+mask.G+mask.B)/3, 1))
+	switch t {
+	case 0:
+		return patternAt(p.A, point)
+	case 1:
+		return patternAt(p.B, point)
 	}
-	return p.B
+	return lerpColor(patternAt(p.A, point), patternAt(p.B, point), t)
 }
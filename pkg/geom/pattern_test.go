@@ -0,0 +1,105 @@
+package geom_test
+
+import (
+	. "github.com/novelalex/soft-raytracer/pkg/geom"
+	nm "github.com/novelalex/soft-raytracer/pkg/nmath"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Nested patterns", func() {
+	white := nm.NewColor(1, 1, 1)
+	black := nm.NewColor(0, 0, 0)
+	red := nm.NewColor(1, 0, 0)
+	blue := nm.NewColor(0, 0, 1)
+
+	It("uses plain colors through SolidPattern", func() {
+		stripes := NewStripePattern(white, black)
+
+		Expect(stripes.A).To(Equal(NewSolidPattern(white)))
+		Expect(stripes.At(nm.NewVec3(0.5, 0, 0))).To(Equal(white))
+		Expect(stripes.At(nm.NewVec3(1.5, 0, 0))).To(Equal(black))
+	})
+
+	It("makes a checker of stripes", func() {
+		stripes := NewStripePattern(red, blue)
+		checkers := NewCheckerPattern(white, black)
+		checkers.A = &stripes
+
+		Expect(checkers.At(nm.NewVec3(0.5, 0.5, 0.5))).To(Equal(red))
+		Expect(checkers.At(nm.NewVec3(0.5, 0.5, 2.5))).To(Equal(red))
+		Expect(checkers.At(nm.NewVec3(1.5, 0.5, 1.5))).To(Equal(blue))
+		Expect(checkers.At(nm.NewVec3(1.5, 0.5, 0.5))).To(Equal(black))
+	})
+
+	It("applies the child's transform after the parent's", func() {
+		stripes := NewStripePattern(red, blue)
+		stripes.SetTransform(nm.NewScaling(0.5, 1, 1))
+		rings := NewRingPattern(white, black)
+		rings.B = &stripes
+		rings.SetTransform(nm.NewScaling(2, 2, 2))
+
+		s := DefaultSphere()
+		s.SetTransform(nm.NewTranslation(10, 0, 0))
+
+		// object x 2.5 is 1.25 in ring space, the second ring,
+		// and 2.5 in stripe space, a stripe of A
+		Expect(rings.AtObject(&s, nm.NewVec3(12.5, 0, 0))).To(Equal(red))
+		// object x 3.3 is 1.65 in ring space and 3.3 in stripe space, a stripe of B
+		Expect(rings.AtObject(&s, nm.NewVec3(13.3, 0, 0))).To(Equal(blue))
+		Expect(rings.AtObject(&s, nm.NewVec3(11, 0, 0))).To(Equal(white))
+	})
+
+	It("blends between nested patterns in a gradient", func() {
+		stripes := NewStripePattern(red, blue)
+		gradient := NewGradientPattern(white, black)
+		gradient.B = &stripes
+
+		Expect(gradient.At(nm.NewVec3(0.25, 0, 0)).AsVec3().ApproxEq(nm.NewVec3(1, 0.75, 0.75))).To(BeTrue())
+		Expect(gradient.At(nm.NewVec3(1.5, 0, 0)).AsVec3().ApproxEq(nm.NewVec3(0.5, 0.5, 1))).To(BeTrue())
+	})
+})
+
+var _ = Describe("BlendPattern", func() {
+	white := nm.NewColor(1, 1, 1)
+	black := nm.NewColor(0, 0, 0)
+
+	It("averages its patterns", func() {
+		stripes := NewStripePattern(white, black)
+		p := NewBlendPattern(BlendAverage, &stripes, NewSolidPattern(nm.NewColor(0.5, 0, 1)), NewSolidPattern(nm.NewColor(0, 1, 0)))
+
+		Expect(p.At(nm.NewVec3(0.5, 0, 0)).AsVec3().ApproxEq(nm.NewVec3(0.5, 2.0/3, 2.0/3))).To(BeTrue())
+		Expect(p.At(nm.NewVec3(1.5, 0, 0)).AsVec3().ApproxEq(nm.NewVec3(0.5/3, 1.0/3, 1.0/3))).To(BeTrue())
+	})
+
+	It("multiplies its patterns", func() {
+		stripes := NewStripePattern(nm.NewColor(1, 0.5, 1), black)
+		p := NewBlendPattern(BlendMultiply, &stripes, NewSolidPattern(nm.NewColor(0.5, 0.5, 0)))
+
+		Expect(p.At(nm.NewVec3(0.5, 0, 0))).To(Equal(nm.NewColor(0.5, 0.25, 0)))
+		Expect(p.At(nm.NewVec3(1.5, 0, 0))).To(Equal(black))
+	})
+
+	It("is black without patterns", func() {
+		p := NewBlendPattern(BlendAverage)
+		Expect(p.At(nm.NewVec3(0, 0, 0))).To(Equal(black))
+	})
+})
+
+var _ = Describe("MaskPattern", func() {
+	red := nm.NewColor(1, 0, 0)
+	blue := nm.NewColor(0, 0, 1)
+
+	It("picks A where the mask is black and B where it is white", func() {
+		mask := NewCheckerPattern(nm.NewColor(0, 0, 0), nm.NewColor(1, 1, 1))
+		p := NewMaskPattern(NewSolidPattern(red), NewSolidPattern(blue), &mask)
+
+		Expect(p.At(nm.NewVec3(0.5, 0.5, 0.5))).To(Equal(red))
+		Expect(p.At(nm.NewVec3(1.5, 0.5, 0.5))).To(Equal(blue))
+	})
+
+	It("mixes by the brightness of gray masks", func() {
+		p := NewMaskPattern(NewSolidPattern(red), NewSolidPattern(blue), NewSolidPattern(nm.NewColor(0.5, 0.25, 0)))
+		Expect(p.At(nm.NewVec3(0, 0, 0)).AsVec3().ApproxEq(nm.NewVec3(0.75, 0, 0.25))).To(BeTrue())
+	})
+})
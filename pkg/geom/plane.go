@@ -7,25 +7,25 @@ import (
 )
 
 type Plane struct {
-	Xf nmath.Mat4
+	Xf nmath.Transform
 }
 
 func DefaultPlane() Plane {
 	return Plane{
-		nmath.Mat4Identity(),
+		nmath.IdentityTransform(),
 	}
 }
 func NewPlane(t nmath.Mat4) Plane {
 	return Plane{
-		t,
+		nmath.NewTransform(t),
 	}
 }
-func (p Plane) Transform() nmath.Mat4 {
+func (p Plane) Transform() nmath.Transform {
 	return p.Xf
 }
 
 func (p *Plane) SetTransform(m nmath.Mat4) {
-	p.Xf = m
+	p.Xf = nmath.NewTransform(m)
 }
 
 func (p *Plane) Translate(x, y, z float64) *Plane {
@@ -33,18 +33,28 @@ func (p *Plane) Translate(x, y, z float64) *Plane {
 	return p
 }
 
-func (p Plane) NormalAt(point nmath.Vec3) nmath.Vec3 {
+func (p Plane) NormalAt(point nmath.Vec3, hit Hit) nmath.Vec3 {
 	object_normal := nmath.NewVec3(0, 1, 0)
-	world_normal := p.Xf.Inverse().Transpose().MultV(object_normal.AsVector4()).DropW()
+	world_normal := p.Xf.InverseTranspose().MultV(object_normal.AsVector4()).DropW()
 	return world_normal.Normalize()
 }
 
-func (p Plane) IntersectRay(r Ray) []float64 {
+// TangentAt is along x, the way PlanarMapping increases u
+func (p Plane) TangentAt(point nmath.Vec3, hit Hit) nmath.Vec3 {
+	return worldTangent(p.Xf, nmath.NewVec3(1, 0, 0))
+}
+
+// Bounds is infinite, planes extend forever in x and z
+func (p Plane) Bounds() AABB {
+	return InfiniteAABB()
+}
+
+func (p Plane) IntersectRay(r Ray) []Hit {
 	ray := r.Transform(p.Xf.Inverse())
 
 	if math.Abs(ray.Dir.Y) < nmath.F64Epsilon {
-		return []float64{}
+		return []Hit{}
 	}
 	t := -ray.Origin.Y / ray.Dir.Y
-	return []float64{t}
+	return []Hit{NewHit(t)}
 }
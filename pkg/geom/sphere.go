@@ -7,27 +7,27 @@ import (
 )
 
 type Sphere struct {
-	Xf nmath.Mat4
+	Xf nmath.Transform
 }
 
 func DefaultSphere() Sphere {
 
 	return Sphere{
-		nmath.Mat4Identity(),
+		nmath.IdentityTransform(),
 	}
 }
 func NewSphere(t nmath.Mat4) Sphere {
 	return Sphere{
-		t,
+		nmath.NewTransform(t),
 	}
 }
 
-func (s Sphere) Transform() nmath.Mat4 {
+func (s Sphere) Transform() nmath.Transform {
 	return s.Xf
 }
 
 func (s *Sphere) SetTransform(m nmath.Mat4) {
-	s.Xf = m
+	s.Xf = nmath.NewTransform(m)
 }
 
 func (s *Sphere) Translate(x, y, z float64) *Sphere {
@@ -60,14 +60,23 @@ func (s *Sphere) RotateZ(angle float64) *Sphere {
 	return s
 }
 
-func (s Sphere) NormalAt(world_point nmath.Vec3) nmath.Vec3 {
+func (s Sphere) NormalAt(world_point nmath.Vec3, hit Hit) nmath.Vec3 {
 	object_point := s.Xf.Inverse().MultV(world_point.AsPoint4())
 	object_normal := object_point.Sub(nmath.NewPoint4(0, 0, 0)).DropW()
-	world_normal := s.Xf.Inverse().Transpose().MultV(object_normal.AsVector4())
+	world_normal := s.Xf.InverseTranspose().MultV(object_normal.AsVector4())
 	return world_normal.DropW().Normalize()
 }
 
-func (s Sphere) IntersectRay(ray Ray) []float64 {
+func (s Sphere) TangentAt(world_point nmath.Vec3, hit Hit) nmath.Vec3 {
+	object_point := s.Xf.Inverse().MultV(world_point.AsPoint4()).DropW()
+	return worldTangent(s.Xf, tangentAroundY(object_point))
+}
+
+func (s Sphere) Bounds() AABB {
+	return unitAABB().Transform(s.Xf.Matrix())
+}
+
+func (s Sphere) IntersectRay(ray Ray) []Hit {
 	D := s.Xf.Inverse().MultV(ray.Dir.AsVector4())
 	S := s.Xf.Inverse().MultV(ray.Origin.AsPoint4())
 	C := nmath.NewPoint4(0, 0, 0)
@@ -83,15 +92,15 @@ func (s Sphere) IntersectRay(ray Ray) []float64 {
 	sol := nmath.Solve(a, b, c)
 
 	if len(sol) == 0 {
-		return []float64{}
+		return []Hit{}
 	}
 
 	if len(sol) == 1 {
-		return []float64{sol[0]}
+		return []Hit{NewHit(sol[0])}
 	}
 
 	t1 := math.Min(sol[0], sol[1])
 	t2 := math.Max(sol[0], sol[1])
-	return []float64{t1, t2}
+	return []Hit{NewHit(t1), NewHit(t2)}
 
 }
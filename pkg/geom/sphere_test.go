@@ -0,0 +1,46 @@
+package geom_test
+
+import (
+	"math"
+
+	. "github.com/novelalex/soft-raytracer/pkg/geom"
+	nm "github.com/novelalex/soft-raytracer/pkg/nmath"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Sphere", func() {
+	Describe("IntersectRay", func() {
+		It("uses the transform set by the chained helpers", func() {
+			s := DefaultSphere()
+			s.Scale(2, 2, 2)
+
+			xs := s.IntersectRay(NewRay(nm.NewVec3(0, 0, -5), nm.NewVec3(0, 0, 1)))
+
+			Expect(xs).To(HaveLen(2))
+			Expect(nm.ApproxEq(xs[0].T, 3)).To(BeTrue())
+			Expect(nm.ApproxEq(xs[1].T, 7)).To(BeTrue())
+		})
+
+		It("uses the transform set by SetTransform", func() {
+			s := DefaultSphere()
+			s.Scale(2, 2, 2)
+			s.SetTransform(nm.NewTranslation(5, 0, 0))
+
+			xs := s.IntersectRay(NewRay(nm.NewVec3(0, 0, -5), nm.NewVec3(0, 0, 1)))
+
+			Expect(xs).To(BeEmpty())
+		})
+	})
+
+	Describe("NormalAt", func() {
+		It("works on a transformed sphere", func() {
+			s := DefaultSphere()
+			s.Scale(1, 0.5, 1).RotateZ(math.Pi / 5)
+
+			n := s.NormalAt(nm.NewVec3(0, math.Sqrt2/2, -math.Sqrt2/2), NewHit(0))
+
+			Expect(nm.LooseEq(n.X, 0) && nm.LooseEq(n.Y, 0.97014) && nm.LooseEq(n.Z, -0.24254)).To(BeTrue())
+		})
+	})
+})
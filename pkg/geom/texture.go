@@ -0,0 +1,262 @@
+package geom
+
+import (
+	"math"
+	"os"
+
+	"github.com/novelalex/soft-raytracer/pkg/gfx"
+	. "github.com/novelalex/soft-raytracer/pkg/nmath"
+)
+
+// TextureFilter is how a texture is sampled between the centers of its pixels
+type TextureFilter int
+
+const (
+	NearestFilter TextureFilter = iota
+	BilinearFilter
+)
+
+// WrapMode is what a texture shows outside of [0, 1] x [0, 1]
+type WrapMode int
+
+const (
+	WrapRepeat WrapMode = iota
+	WrapClamp
+	WrapMirror
+)
+
+// Texture is an image looked up by texture coordinates, (0, 0) is the
+// bottom left corner of the image and (1, 1) the top right
+type Texture struct {
+	Image  gfx.Canvas
+	Filter TextureFilter
+	Wrap   WrapMode
+	// Cells splits the image into a grid of columns and rows that are separate
+	// images, like the faces of CubeMapping, so filtering doesn't blend texels
+	// from neighbouring cells. Zero means the image is a single cell.
+	Cells [2]int
+}
+
+func NewTexture(image gfx.Canvas) Texture {
+	return Texture{image, BilinearFilter, WrapRepeat, [2]int{}}
+}
+
+// LoadTexture reads a PNG or PPM file, its sRGB values are converted to the
+// linear values the renderer works with
+func LoadTexture(path string) (Texture, error) {
+	t, err := LoadDataTexture(path)
+	if err != nil {
+		return Texture{}, err
+	}
+	for y := range t.Image.Height() {
+		for x := range t.Image.Width() {
+			p := t.Image.PixelAt(x, y)
+			t.Image.WritePixel(x, y, Color{
+				R: gfx.SRGBToLinear(p.R),
+				G: gfx.SRGBToLinear(p.G),
+				B: gfx.SRGBToLinear(p.B),
+				A: p.A,
+			})
+		}
+	}
+	return t, nil
+}
+
+// LoadDataTexture reads a PNG or PPM file that holds data instead of colors,
+// like a normal map, so its values are kept as they are stored
+func LoadDataTexture(path string) (Texture, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Texture{}, err
+	}
+	defer f.Close()
+
+	image, err := gfx.DecodeImage(f)
+	if err != nil {
+		return Texture{}, err
+	}
+	return NewTexture(image), nil
+}
+
+func (t Texture) At(u, v float64) Color {
+	w := float64(t.Image.Width())
+	h := float64(t.Image.Height())
+	if w == 0 || h == 0 {
+		return NewColor(0, 0, 0)
+	}
+
+	// continuous pixel coordinates with y going down the image
+	x := u * w
+	y := (1 - v) * h
+
+	if t.Filter == NearestFilter {
+		return t.texel(int(math.Floor(x)), int(math.Floor(y)))
+	}
+
+	x_lo, x_hi := cellRange(x, w, t.Cells[0])
+	y_lo, y_hi := cellRange(y, h, t.Cells[1])
+
+	x -= 0.5
+	y -= 0.5
+	x0, y0 := math.Floor(x), math.Floor(y)
+	fx, fy := x-x0, y-y0
+	ix0, iy0 := max(x_lo, min(int(x0), x_hi)), max(y_lo, min(int(y0), y_hi))
+	ix1, iy1 := max(x_lo, min(int(x0)+1, x_hi)), max(y_lo, min(int(y0)+1, y_hi))
+
+	top := lerpColor(t.texel(ix0, iy0), t.texel(ix1, iy0), fx)
+	bottom := lerpColor(t.texel(ix0, iy1), t.texel(ix1, iy1), fx)
+	return lerpColor(top, bottom, fy)
+}
+
+// cellRange is the first and last pixel of the cell that pixel coordinate pos
+// is in, when an image size pixels across is split into cells. With no cells
+// the range doesn't limit anything.
+func cellRange(pos, size float64, cells int) (int, int) {
+	if cells <= 0 {
+		return math.MinInt, math.MaxInt
+	}
+	cell := math.Floor(pos * float64(cells) / size)
+	lo := int(math.Floor(cell * size / float64(cells)))
+	hi := int(math.Floor((cell+1)*size/float64(cells))) - 1
+	return lo, max(lo, hi)
+}
+
+// texel is the pixel at (x, y) after the wrap mode brings it inside the image
+func (t Texture) texel(x, y int) Color {
+	return t.Image.PixelAt(
+		uint(t.Wrap.apply(x, int(t.Image.Width()))),
+		uint(t.Wrap.apply(y, int(t.Image.Height()))),
+	)
+}
+
+func (m WrapMode) apply(i, n int) int {
+	switch m {
+	case WrapClamp:
+		return max(0, min(i, n-1))
+	case WrapMirror:
+		i = ((i % (2 * n)) + 2*n) % (2 * n)
+		if i >= n {
+			return 2*n - 1 - i
+		}
+		return i
+	}
+	return ((i % n) + n) % n
+}
+
+func lerpColor(a, b Color, t float64) Color {
+	return Color{
+		R: a.R + (b.R-a.R)*t,
+		G: a.G + (b.G-a.G)*t,
+		B: a.B + (b.B-a.B)*t,
+		A: a.A + (b.A-a.A)*t,
+	}
+}
+
+// UVMapping turns a point in pattern space into texture coordinates
+type UVMapping func(p Vec3) (u, v float64)
+
+// PlanarMapping projects along y, u follows x and v follows z.
+// The texture covers one unit square and the wrap mode decides the rest.
+func PlanarMapping(p Vec3) (float64, float64) {
+	return p.X, p.Z
+}
+
+// SphericalMapping wraps the texture around the unit sphere,
+// u goes around the y axis and v from the south pole to the north
+func SphericalMapping(p Vec3) (float64, float64) {
+	theta := math.Atan2(p.X, p.Z)
+	radius := p.Mag()
+	if radius == 0 {
+		return 0.5, 0.5
+	}
+	phi := math.Acos(max(-1, min(p.Y/radius, 1)))
+	u := 1 - (theta/(2*math.Pi) + 0.5)
+	v := 1 - phi/math.Pi
+	return u, v
+}
+
+// CylindricalMapping wraps the texture once around the y axis,
+// v follows y so the texture repeats up a cylinder every unit
+func CylindricalMapping(p Vec3) (float64, float64) {
+	theta := math.Atan2(p.X, p.Z)
+	u := 1 - (theta/(2*math.Pi) + 0.5)
+	return u, p.Y
+}
+
+// CubeMapping maps each face of the unit cube to one cell of a texture laid
+// out as a horizontal cross, four cells wide and three tall:
+//
+//	      up
+//	left  front right back
+//	      down
+//
+// front is the +z face. Set the Cells of the texture to 4 by 3 so bilinear
+// filtering doesn't blend the edges of the faces with the unused cells.
+func CubeMapping(p Vec3) (float64, float64) {
+	var u, v float64
+	var col, row int
+
+	abs_x, abs_y, abs_z := math.Abs(p.X), math.Abs(p.Y), math.Abs(p.Z)
+	coord := max(abs_x, abs_y, abs_z)
+	if coord == 0 {
+		return 0, 0
+	}
+	x, y, z := p.X/coord, p.Y/coord, p.Z/coord
+
+	switch coord {
+	case p.X: // right
+		u, v = (1-z)/2, (y+1)/2
+		col, row = 2, 1
+	case -p.X: // left
+		u, v = (z+1)/2, (y+1)/2
+		col, row = 0, 1
+	case p.Y: // up
+		u, v = (x+1)/2, (1-z)/2
+		col, row = 1, 0
+	case -p.Y: // down
+		u, v = (x+1)/2, (z+1)/2
+		col, row = 1, 2
+	case p.Z: // front
+		u, v = (x+1)/2, (y+1)/2
+		col, row = 1, 1
+	default: // back
+		u, v = (1-x)/2, (y+1)/2
+		col, row = 3, 1
+	}
+
+	// keep the coordinates of the face's edges inside its cell
+	u = max(0, min(u, math.Nextafter(1, 0)))
+	v = max(0, min(v, math.Nextafter(1, 0)))
+	return (float64(col) + u) / 4, (float64(2-row) + v) / 3
+}
+
+// ImagePattern looks up a texture with texture coordinates from Mapping
+type ImagePattern struct {
+	Texture Texture
+	Mapping UVMapping
+	Xf      Transform
+}
+
+func NewImagePattern(texture Texture, mapping UVMapping) ImagePattern {
+	return ImagePattern{texture, mapping, IdentityTransform()}
+}
+
+func (p ImagePattern) Transform() Transform {
+	return p.Xf
+}
+
+func (p *ImagePattern) SetTransform(m Mat4) {
+	p.Xf = NewTransform(m)
+}
+
+func (p ImagePattern) AtObject(obj Shape, point Vec3) Color {
+	return objectPatternAt(p, obj, point)
+}
+
+func (p ImagePattern) At(point Vec3) Color {
+	mapping := p.Mapping
+	if mapping == nil {
+		mapping = PlanarMapping
+	}
+	return p.Texture.At(mapping(point))
+}
@@ -0,0 +1,172 @@
+package geom_test
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+
+	. "github.com/novelalex/soft-raytracer/pkg/geom"
+	"github.com/novelalex/soft-raytracer/pkg/gfx"
+	nm "github.com/novelalex/soft-raytracer/pkg/nmath"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Texture", func() {
+	// 2x2 image, black and white on the top row, red and green on the bottom
+	newImage := func() gfx.Canvas {
+		c := gfx.NewCanvas(2, 2)
+		c.WritePixel(0, 0, nm.NewColor(0, 0, 0))
+		c.WritePixel(1, 0, nm.NewColor(1, 1, 1))
+		c.WritePixel(0, 1, nm.NewColor(1, 0, 0))
+		c.WritePixel(1, 1, nm.NewColor(0, 1, 0))
+		return c
+	}
+
+	It("puts (0, 0) at the bottom left with nearest filtering", func() {
+		t := NewTexture(newImage())
+		t.Filter = NearestFilter
+
+		Expect(t.At(0.25, 0.25)).To(Equal(nm.NewColor(1, 0, 0)))
+		Expect(t.At(0.75, 0.25)).To(Equal(nm.NewColor(0, 1, 0)))
+		Expect(t.At(0.25, 0.75)).To(Equal(nm.NewColor(0, 0, 0)))
+		Expect(t.At(0.75, 0.75)).To(Equal(nm.NewColor(1, 1, 1)))
+	})
+
+	It("interpolates between pixel centers with bilinear filtering", func() {
+		t := NewTexture(newImage())
+		t.Wrap = WrapClamp
+
+		Expect(t.At(0.25, 0.25)).To(Equal(nm.NewColor(1, 0, 0)))
+		Expect(t.At(0.5, 0.25)).To(Equal(nm.NewColor(0.5, 0.5, 0)))
+		Expect(t.At(0.5, 0.5)).To(Equal(nm.NewColor(0.5, 0.5, 0.25)))
+	})
+
+	DescribeTable("wraps coordinates outside the image",
+		func(wrap WrapMode, u float64, expected nm.Color) {
+			t := NewTexture(newImage())
+			t.Filter = NearestFilter
+			t.Wrap = wrap
+			Expect(t.At(u, 0.25)).To(Equal(expected))
+		},
+		Entry("repeat", WrapRepeat, 1.25, nm.NewColor(1, 0, 0)),
+		Entry("repeat below zero", WrapRepeat, -0.25, nm.NewColor(0, 1, 0)),
+		Entry("clamp", WrapClamp, 3.25, nm.NewColor(0, 1, 0)),
+		Entry("clamp below zero", WrapClamp, -2.0, nm.NewColor(1, 0, 0)),
+		Entry("mirror", WrapMirror, 1.25, nm.NewColor(0, 1, 0)),
+		Entry("mirror twice", WrapMirror, 2.25, nm.NewColor(1, 0, 0)),
+		Entry("mirror below zero", WrapMirror, -0.25, nm.NewColor(1, 0, 0)),
+	)
+
+	It("loads PNG and PPM files as linear colors", func() {
+		c := gfx.NewCanvas(1, 1)
+		c.WritePixel(0, 0, nm.NewColor(1, 0.5, 0))
+		path := filepath.Join(GinkgoT().TempDir(), "texture.ppm")
+		Expect(os.WriteFile(path, c.AsP6PPM(), 0644)).To(Succeed())
+
+		t, err := LoadTexture(path)
+		Expect(err).NotTo(HaveOccurred())
+		p := t.Image.PixelAt(0, 0)
+		Expect(p.R).To(Equal(1.0))
+		Expect(p.G).To(BeNumerically("~", gfx.SRGBToLinear(128.0/255), 1e-9))
+		Expect(p.B).To(Equal(0.0))
+	})
+})
+
+var _ = Describe("UV mappings", func() {
+	expectUV := func(u, v, eu, ev float64) {
+		Expect(u).To(BeNumerically("~", eu, 1e-9))
+		Expect(v).To(BeNumerically("~", ev, 1e-9))
+	}
+
+	DescribeTable("planar",
+		func(p nm.Vec3, eu, ev float64) {
+			u, v := PlanarMapping(p)
+			expectUV(u, v, eu, ev)
+		},
+		Entry(nil, nm.NewVec3(0.25, 0, 0.5), 0.25, 0.5),
+		Entry(nil, nm.NewVec3(1.25, 3, -0.5), 1.25, -0.5),
+	)
+
+	DescribeTable("spherical",
+		func(p nm.Vec3, eu, ev float64) {
+			u, v := SphericalMapping(p)
+			expectUV(u, v, eu, ev)
+		},
+		Entry(nil, nm.NewVec3(0, 0, -1), 0.0, 0.5),
+		Entry(nil, nm.NewVec3(1, 0, 0), 0.25, 0.5),
+		Entry(nil, nm.NewVec3(0, 0, 1), 0.5, 0.5),
+		Entry(nil, nm.NewVec3(-1, 0, 0), 0.75, 0.5),
+		Entry(nil, nm.NewVec3(0, 1, 0), 0.5, 1.0),
+		Entry(nil, nm.NewVec3(0, -1, 0), 0.5, 0.0),
+		Entry(nil, nm.NewVec3(math.Sqrt2/2, math.Sqrt2/2, 0), 0.25, 0.75),
+	)
+
+	DescribeTable("cylindrical",
+		func(p nm.Vec3, eu, ev float64) {
+			u, v := CylindricalMapping(p)
+			expectUV(u, v, eu, ev)
+		},
+		Entry(nil, nm.NewVec3(0, 0, -1), 0.0, 0.0),
+		Entry(nil, nm.NewVec3(0, 0.5, -1), 0.0, 0.5),
+		Entry(nil, nm.NewVec3(1, 1.25, 0), 0.25, 1.25),
+		Entry(nil, nm.NewVec3(-1, -0.25, 0), 0.75, -0.25),
+	)
+
+	DescribeTable("cube faces",
+		func(p nm.Vec3, eu, ev float64) {
+			u, v := CubeMapping(p)
+			expectUV(u, v, eu, ev)
+		},
+		Entry("front", nm.NewVec3(0, 0, 1), 1.5/4, 1.5/3),
+		Entry("right", nm.NewVec3(1, 0, 0), 2.5/4, 1.5/3),
+		Entry("back", nm.NewVec3(0, 0, -1), 3.5/4, 1.5/3),
+		Entry("left", nm.NewVec3(-1, 0, 0), 0.5/4, 1.5/3),
+		Entry("up", nm.NewVec3(0, 1, 0), 1.5/4, 2.5/3),
+		Entry("down", nm.NewVec3(0, -1, 0), 1.5/4, 0.5/3),
+		Entry("front corner", nm.NewVec3(-0.5, -0.5, 1), 1.25/4, 1.25/3),
+		Entry("up toward the back", nm.NewVec3(0, 1, -0.5), 1.5/4, 2.75/3),
+	)
+
+	It("keeps bilinear filtering of a cube texture inside each face", func() {
+		// faces are white, the unused cells of the cross black
+		image := gfx.NewCanvas(8, 6)
+		for y := range uint(6) {
+			for x := range uint(8) {
+				c := nm.NewColor(0, 0, 0)
+				if y/2 == 1 || x/2 == 1 {
+					c = nm.NewColor(1, 1, 1)
+				}
+				image.WritePixel(x, y, c)
+			}
+		}
+		texture := NewTexture(image)
+		// the left edge of the up face is next to the empty top left cell
+		edge := nm.NewVec3(-0.99, 1, 0)
+
+		Expect(texture.At(CubeMapping(edge)).R).To(BeNumerically("<", 0.9))
+
+		texture.Cells = [2]int{4, 3}
+		Expect(texture.At(CubeMapping(edge))).To(Equal(nm.NewColor(1, 1, 1)))
+		Expect(texture.At(CubeMapping(nm.NewVec3(0.99, 0.99, -1)))).To(Equal(nm.NewColor(1, 1, 1)))
+	})
+})
+
+var _ = Describe("ImagePattern", func() {
+	It("looks up the texture with the mapping in pattern space", func() {
+		texture := NewTexture(gfx.NewCanvas(2, 1))
+		texture.Filter = NearestFilter
+		texture.Image.WritePixel(0, 0, nm.NewColor(0, 0, 0))
+		texture.Image.WritePixel(1, 0, nm.NewColor(1, 1, 1))
+
+		p := NewImagePattern(texture, PlanarMapping)
+		Expect(p.At(nm.NewVec3(0.25, 0, 0))).To(Equal(nm.NewColor(0, 0, 0)))
+		Expect(p.At(nm.NewVec3(0.75, 0, 0))).To(Equal(nm.NewColor(1, 1, 1)))
+
+		s := DefaultSphere()
+		s.SetTransform(nm.NewScaling(2, 2, 2))
+		p.SetTransform(nm.NewTranslation(0.5, 0, 0))
+		Expect(p.AtObject(&s, nm.NewVec3(1.5, 0, 0))).To(Equal(nm.NewColor(0, 0, 0)))
+		Expect(p.AtObject(&s, nm.NewVec3(2.5, 0, 0))).To(Equal(nm.NewColor(1, 1, 1)))
+	})
+})
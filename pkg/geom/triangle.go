@@ -0,0 +1,106 @@
+package geom
+
+import (
+	"math"
+
+	"github.com/novelalex/soft-raytracer/pkg/nmath"
+)
+
+// Triangle is a flat triangle, the edges and normal are precomputed
+// by NewTriangle so the points shouldn't be modified afterwards
+type Triangle struct {
+	Xf     nmath.Transform
+	P1     nmath.Vec3
+	P2     nmath.Vec3
+	P3     nmath.Vec3
+	E1     nmath.Vec3
+	E2     nmath.Vec3
+	Normal nmath.Vec3
+}
+
+func NewTriangle(p1, p2, p3 nmath.Vec3) Triangle {
+	e1 := p2.Sub(p1)
+	e2 := p3.Sub(p1)
+	return Triangle{
+		nmath.IdentityTransform(),
+		p1, p2, p3,
+		e1, e2,
+		e2.Cross(e1).Normalize(),
+	}
+}
+
+func (t Triangle) Transform() nmath.Transform {
+	return t.Xf
+}
+
+func (t *Triangle) SetTransform(m nmath.Mat4) {
+	t.Xf = nmath.NewTransform(m)
+}
+
+func (t Triangle) NormalAt(world_point nmath.Vec3, hit Hit) nmath.Vec3 {
+	world_normal := t.Xf.InverseTranspose().MultV(t.Normal.AsVector4())
+	return world_normal.DropW().Normalize()
+}
+
+// TangentAt is along the edge from P1 to P2
+func (t Triangle) TangentAt(world_point nmath.Vec3, hit Hit) nmath.Vec3 {
+	return worldTangent(t.Xf, t.E1)
+}
+
+func (t Triangle) Bounds() AABB {
+	return EmptyAABB().AddPoint(t.P1).AddPoint(t.P2).AddPoint(t.P3).Transform(t.Xf.Matrix())
+}
+
+// localIntersectRay is the Möller-Trumbore test, u and v of the hit are the
+// barycentric weights of P2 and P3
+func (t Triangle) localIntersectRay(r Ray) []Hit {
+	dir_cross_e2 := r.Dir.Cross(t.E2)
+	det := t.E1.Dot(dir_cross_e2)
+	if math.Abs(det) < nmath.F64Epsilon {
+		return []Hit{}
+	}
+
+	f := 1.0 / det
+	p1_to_origin := r.Origin.Sub(t.P1)
+	u := f * p1_to_origin.Dot(dir_cross_e2)
+	if u < 0 || u > 1 {
+		return []Hit{}
+	}
+
+	origin_cross_e1 := p1_to_origin.Cross(t.E1)
+	v := f * r.Dir.Dot(origin_cross_e1)
+	if v < 0 || u+v > 1 {
+		return []Hit{}
+	}
+
+	return []Hit{NewHitUV(f*t.E2.Dot(origin_cross_e1), u, v)}
+}
+
+func (t Triangle) IntersectRay(r Ray) []Hit {
+	ray := r.Transform(t.Xf.Inverse())
+	return t.localIntersectRay(ray)
+}
+
+// SmoothTriangle is a Triangle that interpolates the vertex normals
+// N1, N2 and N3 across its surface using the barycentric u and v of the hit
+type SmoothTriangle struct {
+	Triangle
+	N1 nmath.Vec3
+	N2 nmath.Vec3
+	N3 nmath.Vec3
+}
+
+func NewSmoothTriangle(p1, p2, p3, n1, n2, n3 nmath.Vec3) SmoothTriangle {
+	return SmoothTriangle{
+		NewTriangle(p1, p2, p3),
+		n1, n2, n3,
+	}
+}
+
+func (t SmoothTriangle) NormalAt(world_point nmath.Vec3, hit Hit) nmath.Vec3 {
+	object_normal := t.N2.Mult(hit.U).
+		Add(t.N3.Mult(hit.V)).
+		Add(t.N1.Mult(1 - hit.U - hit.V))
+	world_normal := t.Xf.InverseTranspose().MultV(object_normal.AsVector4())
+	return world_normal.DropW().Normalize()
+}
@@ -0,0 +1,75 @@
+package geom_test
+
+import (
+	. "github.com/novelalex/soft-raytracer/pkg/geom"
+	nm "github.com/novelalex/soft-raytracer/pkg/nmath"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Triangle", func() {
+	t := NewTriangle(nm.NewVec3(0, 1, 0), nm.NewVec3(-1, 0, 0), nm.NewVec3(1, 0, 0))
+
+	Describe("NewTriangle", func() {
+		It("precomputes the edges and normal", func() {
+			Expect(t.E1.ApproxEq(nm.NewVec3(-1, -1, 0))).To(BeTrue())
+			Expect(t.E2.ApproxEq(nm.NewVec3(1, -1, 0))).To(BeTrue())
+			Expect(t.Normal.ApproxEq(nm.NewVec3(0, 0, -1))).To(BeTrue())
+		})
+	})
+
+	Describe("IntersectRay", func() {
+		It("misses rays parallel to the triangle", func() {
+			xs := t.IntersectRay(NewRay(nm.NewVec3(0, -1, -2), nm.NewVec3(0, 1, 0)))
+			Expect(xs).To(BeEmpty())
+		})
+
+		It("misses rays past each edge", func() {
+			origins := []nm.Vec3{
+				nm.NewVec3(1, 1, -2),
+				nm.NewVec3(-1, 1, -2),
+				nm.NewVec3(0, -1, -2),
+			}
+			for _, o := range origins {
+				xs := t.IntersectRay(NewRay(o, nm.NewVec3(0, 0, 1)))
+				Expect(xs).To(BeEmpty())
+			}
+		})
+
+		It("hits the triangle", func() {
+			xs := t.IntersectRay(NewRay(nm.NewVec3(0, 0.5, -2), nm.NewVec3(0, 0, 1)))
+			Expect(xs).To(HaveLen(1))
+			Expect(nm.ApproxEq(xs[0].T, 2)).To(BeTrue())
+		})
+	})
+
+	Describe("NormalAt", func() {
+		It("is the same everywhere", func() {
+			Expect(t.NormalAt(nm.NewVec3(0, 0.5, 0), NewHit(0)).ApproxEq(t.Normal)).To(BeTrue())
+			Expect(t.NormalAt(nm.NewVec3(-0.5, 0.75, 0), NewHit(0)).ApproxEq(t.Normal)).To(BeTrue())
+		})
+	})
+})
+
+var _ = Describe("SmoothTriangle", func() {
+	t := NewSmoothTriangle(
+		nm.NewVec3(0, 1, 0), nm.NewVec3(-1, 0, 0), nm.NewVec3(1, 0, 0),
+		nm.NewVec3(0, 1, 0), nm.NewVec3(-1, 0, 0), nm.NewVec3(1, 0, 0),
+	)
+
+	Describe("IntersectRay", func() {
+		It("stores u and v on the hit", func() {
+			xs := t.IntersectRay(NewRay(nm.NewVec3(-0.2, 0.3, -2), nm.NewVec3(0, 0, 1)))
+			Expect(xs).To(HaveLen(1))
+			Expect(nm.ApproxEq(xs[0].U, 0.45)).To(BeTrue())
+			Expect(nm.ApproxEq(xs[0].V, 0.25)).To(BeTrue())
+		})
+	})
+
+	Describe("NormalAt", func() {
+		It("interpolates the vertex normals", func() {
+			n := t.NormalAt(nm.NewVec3(0, 0, 0), NewHitUV(1, 0.45, 0.25))
+			Expect(nm.LooseEq(n.X, -0.5547) && nm.LooseEq(n.Y, 0.83205) && nm.LooseEq(n.Z, 0)).To(BeTrue())
+		})
+	})
+})
@@ -2,7 +2,6 @@ package gfx
 
 import (
 	"fmt"
-	"math"
 	"strings"
 
 	. "github.com/novelalex/soft-raytracer/pkg/nmath"
@@ -15,6 +14,19 @@ type Canvas struct {
 	buffer []Color
 }
 
+// maxDecodePixels limits the images the decoders allocate, so a corrupt
+// header can't ask for gigabytes. It is a little more than 8192 x 8192.
+const maxDecodePixels = 1 << 26
+
+// checkDecodeSize is an error for a header asking for more than
+// maxDecodePixels, either side alone is checked so rows stay small too
+func checkDecodeSize(format string, width, height uint64) error {
+	if width > maxDecodePixels || height > maxDecodePixels || width*height > maxDecodePixels {
+		return fmt.Errorf("gfx: %s image of %dx%d pixels is too large", format, width, height)
+	}
+	return nil
+}
+
 func NewCanvas(width, height uint) Canvas {
 	buffer_size := width * height
 	buffer := make([]Color, buffer_size)
@@ -49,7 +61,7 @@ func (c Canvas) constructP6PPMBody() []byte {
 	result := []byte{}
 	for _, color := range c.buffer {
 		for i := range 3 {
-			cc := uint8(math.Max(0, math.Min(math.Round(color.At(i)*255), 255)))
+			cc := quantize8(color.At(i))
 			result = append(result, byte(cc))
 		}
 	}
@@ -71,7 +83,7 @@ func (c Canvas) constructPPMBody() string {
 
 	for _, color := range c.buffer {
 		for i := range 3 {
-			cc := uint8(math.Max(0, math.Min(math.Round(color.At(i)*255), 255)))
+			cc := quantize8(color.At(i))
 
 			written_digits := fmt.Sprintf("%d", cc)
 			width_needed := len(written_digits) + nutil.IntFromBool(!ln_start)
@@ -0,0 +1,419 @@
+package gfx
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+type EXRCompression byte
+
+const (
+	EXRNoCompression EXRCompression = 0
+	// EXRZIPCompression deflates blocks of 16 scanlines
+	EXRZIPCompression EXRCompression = 3
+	// exrZIPSCompression deflates single scanlines, it is only read
+	exrZIPSCompression EXRCompression = 2
+)
+
+const (
+	exrMagic = 20000630
+
+	// limit on the attributes DecodeEXR will allocate, so a corrupt file
+	// can't ask for gigabytes
+	exrMaxAttributeSize = 1 << 20
+
+	exrUint  = 0
+	exrHalf  = 1
+	exrFloat = 2
+)
+
+func (c EXRCompression) linesPerChunk() int {
+	if c == EXRZIPCompression {
+		return 16
+	}
+	return 1
+}
+
+// EncodeEXR writes the canvas as a scanline OpenEXR file with 32 bit float
+// R, G and B channels, so nothing is lost
+func (c Canvas) EncodeEXR(w io.Writer, compression EXRCompression) error {
+	if compression != EXRNoCompression && compression != EXRZIPCompression {
+		return fmt.Errorf("gfx: unsupported EXR compression %d", compression)
+	}
+
+	le := binary.LittleEndian
+	header := le.AppendUint32(nil, exrMagic)
+	header = le.AppendUint32(header, 2)
+
+	attribute := func(name, kind string, value []byte) {
+		header = append(header, name+"\x00"+kind+"\x00"...)
+		header = le.AppendUint32(header, uint32(len(value)))
+		header = append(header, value...)
+	}
+	float := func(f float32) []byte {
+		return le.AppendUint32(nil, math.Float32bits(f))
+	}
+
+	// channels have to be sorted by name, each one is its pixel type, a
+	// linear flag, three reserved bytes and its x and y sampling
+	var chlist []byte
+	for _, name := range []string{"B", "G", "R"} {
+		chlist = append(chlist, name+"\x00"...)
+		chlist = le.AppendUint32(chlist, exrFloat)
+		chlist = append(chlist, 0, 0, 0, 0)
+		chlist = le.AppendUint32(chlist, 1)
+		chlist = le.AppendUint32(chlist, 1)
+	}
+	chlist = append(chlist, 0)
+
+	var window []byte
+	for _, v := range []int32{0, 0, int32(c.width) - 1, int32(c.height) - 1} {
+		window = le.AppendUint32(window, uint32(v))
+	}
+	attribute("channels", "chlist", chlist)
+	attribute("compression", "compression", []byte{byte(compression)})
+	attribute("dataWindow", "box2i", window)
+	attribute("displayWindow", "box2i", window)
+	attribute("lineOrder", "lineOrder", []byte{0})
+	attribute("pixelAspectRatio", "float", float(1))
+	attribute("screenWindowCenter", "v2f", append(float(0), float(0)...))
+	attribute("screenWindowWidth", "float", float(1))
+	header = append(header, 0)
+
+	lines := compression.linesPerChunk()
+	chunk_count := (int(c.height) + lines - 1) / lines
+	chunks := make([][]byte, chunk_count)
+	for i := range chunks {
+		y0 := i * lines
+		y1 := min(y0+lines, int(c.height))
+		data := c.exrChunk(y0, y1)
+		if compression == EXRZIPCompression {
+			data = exrZIPCompress(data)
+		}
+		chunks[i] = data
+	}
+
+	// the offset table points at each chunk from the start of the file
+	offset := uint64(len(header) + chunk_count*8)
+	for _, chunk := range chunks {
+		header = le.AppendUint64(header, offset)
+		offset += uint64(8 + len(chunk))
+	}
+
+	bw := bufio.NewWriter(w)
+	if _, err := bw.Write(header); err != nil {
+		return err
+	}
+	for i, chunk := range chunks {
+		var prefix [8]byte
+		le.PutUint32(prefix[0:], uint32(i*lines))
+		le.PutUint32(prefix[4:], uint32(len(chunk)))
+		if _, err := bw.Write(prefix[:]); err != nil {
+			return err
+		}
+		if _, err := bw.Write(chunk); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// exrChunk is the uncompressed data of the scanlines [y0, y1),
+// each line has every value of B, then G, then R
+func (c Canvas) exrChunk(y0, y1 int) []byte {
+	data := make([]byte, 0, (y1-y0)*int(c.width)*12)
+	for y := y0; y < y1; y++ {
+		for i := 2; i >= 0; i-- {
+			for x := range c.width {
+				data = binary.LittleEndian.AppendUint32(data, math.Float32bits(float32(c.PixelAt(x, uint(y)).At(i))))
+			}
+		}
+	}
+	return data
+}
+
+// exrZIPCompress splits the bytes into two halves, delta encodes them
+// and deflates the result. Data that doesn't shrink is stored as is.
+func exrZIPCompress(data []byte) []byte {
+	t := make([]byte, len(data))
+	half := (len(data) + 1) / 2
+	for i, b := range data {
+		if i%2 == 0 {
+			t[i/2] = b
+		} else {
+			t[half+i/2] = b
+		}
+	}
+	for i := len(t) - 1; i > 0; i-- {
+		t[i] = byte(int(t[i]) - int(t[i-1]) + 128 + 256)
+	}
+
+	var b bytes.Buffer
+	zw := zlib.NewWriter(&b)
+	_, err := zw.Write(t)
+	if close_err := zw.Close(); err == nil {
+		err = close_err
+	}
+	if err != nil || b.Len() >= len(data) {
+		return data
+	}
+	return b.Bytes()
+}
+
+func exrZIPDecompress(data []byte, size int) ([]byte, error) {
+	zr, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	// read instead of allocating size up front, so a chunk only gets
+	// as much memory as its data really decompresses to
+	t, err := io.ReadAll(io.LimitReader(zr, int64(size)))
+	if err != nil {
+		return nil, err
+	}
+	if len(t) != size {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	for i := 1; i < len(t); i++ {
+		t[i] = byte(int(t[i-1]) + int(t[i]) - 128)
+	}
+	result := make([]byte, size)
+	half := (size + 1) / 2
+	for i := range result {
+		if i%2 == 0 {
+			result[i] = t[i/2]
+		} else {
+			result[i] = t[half+i/2]
+		}
+	}
+	return result, nil
+}
+
+type exrChannel struct {
+	name       string
+	pixel_type int32
+}
+
+func (ch exrChannel) size() int {
+	if ch.pixel_type == exrHalf {
+		return 2
+	}
+	return 4
+}
+
+// DecodeEXR reads a scanline OpenEXR file without compression or with ZIP or
+// ZIPS compression. The R, G, B and A channels are read in any pixel type,
+// alpha is 1 if there is no A channel.
+func DecodeEXR(r io.Reader) (Canvas, error) {
+	br := bufio.NewReader(r)
+	le := binary.LittleEndian
+
+	var magic, version uint32
+	if err := binary.Read(br, le, &magic); err != nil || magic != exrMagic {
+		return Canvas{}, errors.New("gfx: not an OpenEXR file")
+	}
+	if err := binary.Read(br, le, &version); err != nil {
+		return Canvas{}, fmt.Errorf("gfx: reading EXR version: %w", err)
+	}
+	// tiled, deep and multi part files aren't supported
+	if version&0xff != 2 || version&0x1a00 != 0 {
+		return Canvas{}, errors.New("gfx: only single part scanline EXR files are supported")
+	}
+
+	var channels []exrChannel
+	compression := EXRNoCompression
+	var window [4]int32
+	has_window := false
+	for {
+		name, err := br.ReadString(0)
+		if err != nil {
+			return Canvas{}, fmt.Errorf("gfx: reading EXR header: %w", err)
+		}
+		if name == "\x00" {
+			break
+		}
+		if _, err := br.ReadString(0); err != nil {
+			return Canvas{}, fmt.Errorf("gfx: reading EXR header: %w", err)
+		}
+		var size uint32
+		if err := binary.Read(br, le, &size); err != nil {
+			return Canvas{}, fmt.Errorf("gfx: reading EXR header: %w", err)
+		}
+		if size > exrMaxAttributeSize {
+			return Canvas{}, fmt.Errorf("gfx: EXR attribute %q is too large", name[:len(name)-1])
+		}
+		value := make([]byte, size)
+		if _, err := io.ReadFull(br, value); err != nil {
+			return Canvas{}, fmt.Errorf("gfx: reading EXR header: %w", err)
+		}
+
+		switch name[:len(name)-1] {
+		case "channels":
+			if channels, err = parseEXRChannels(value); err != nil {
+				return Canvas{}, err
+			}
+		case "compression":
+			if len(value) != 1 {
+				return Canvas{}, errors.New("gfx: EXR compression attribute has the wrong size")
+			}
+			compression = EXRCompression(value[0])
+		case "dataWindow":
+			if len(value) != 16 || binary.Read(bytes.NewReader(value), le, &window) != nil {
+				return Canvas{}, errors.New("gfx: EXR dataWindow attribute has the wrong size")
+			}
+			has_window = true
+		}
+	}
+	if len(channels) == 0 || !has_window {
+		return Canvas{}, errors.New("gfx: EXR header is missing channels or dataWindow")
+	}
+	if compression != EXRNoCompression && compression != EXRZIPCompression && compression != exrZIPSCompression {
+		return Canvas{}, fmt.Errorf("gfx: unsupported EXR compression %d", compression)
+	}
+
+	// 64 bit math so windows spanning the whole int32 range can't overflow
+	width64 := int64(window[2]) - int64(window[0]) + 1
+	height64 := int64(window[3]) - int64(window[1]) + 1
+	if width64 < 1 || height64 < 1 {
+		return Canvas{}, errors.New("gfx: EXR dataWindow is empty")
+	}
+	if err := checkDecodeSize("EXR", uint64(width64), uint64(height64)); err != nil {
+		return Canvas{}, err
+	}
+	width, height := int(width64), int(height64)
+	line_size := 0
+	for _, ch := range channels {
+		line_size += ch.size() * width
+	}
+
+	lines := compression.linesPerChunk()
+	chunk_count := (height + lines - 1) / lines
+	if _, err := br.Discard(chunk_count * 8); err != nil {
+		return Canvas{}, fmt.Errorf("gfx: reading EXR offsets: %w", err)
+	}
+
+	// the canvas is made once the first chunk has been read, a corrupt
+	// dataWindow then fails on the chunk sizes before allocating anything
+	var c Canvas
+	for range chunk_count {
+		var y, size int32
+		if err := binary.Read(br, le, &y); err != nil {
+			return Canvas{}, fmt.Errorf("gfx: reading EXR chunk: %w", err)
+		}
+		if err := binary.Read(br, le, &size); err != nil {
+			return Canvas{}, fmt.Errorf("gfx: reading EXR chunk: %w", err)
+		}
+
+		y0 := int(int64(y) - int64(window[1]))
+		if y0 < 0 || y0 >= height {
+			return Canvas{}, fmt.Errorf("gfx: EXR chunk at line %d is outside the image", y)
+		}
+		y1 := min(y0+lines, height)
+		expected := (y1 - y0) * line_size
+		// compressed chunks that wouldn't shrink are stored as they are
+		if size < 0 || int(size) > expected {
+			return Canvas{}, fmt.Errorf("gfx: EXR chunk at line %d has the wrong size", y)
+		}
+		data := make([]byte, size)
+		if _, err := io.ReadFull(br, data); err != nil {
+			return Canvas{}, fmt.Errorf("gfx: reading EXR chunk: %w", err)
+		}
+
+		if compression != EXRNoCompression && int(size) < expected {
+			var err error
+			if data, err = exrZIPDecompress(data, expected); err != nil {
+				return Canvas{}, fmt.Errorf("gfx: decompressing EXR chunk: %w", err)
+			}
+		}
+		if len(data) != expected {
+			return Canvas{}, fmt.Errorf("gfx: EXR chunk at line %d has the wrong size", y)
+		}
+
+		if c.buffer == nil {
+			c = NewCanvas(uint(width), uint(height))
+			for i := range c.buffer {
+				c.buffer[i].A = 1
+			}
+		}
+		c.readEXRChunk(data, channels, y0, y1)
+	}
+	return c, nil
+}
+
+func parseEXRChannels(value []byte) ([]exrChannel, error) {
+	channels := []exrChannel{}
+	for len(value) > 0 && value[0] != 0 {
+		end := bytes.IndexByte(value, 0)
+		if end < 0 || len(value) < end+17 {
+			return nil, errors.New("gfx: EXR channel list is truncated")
+		}
+		name := string(value[:end])
+		pixel_type := int32(binary.LittleEndian.Uint32(value[end+1:]))
+		if pixel_type != exrUint && pixel_type != exrHalf && pixel_type != exrFloat {
+			return nil, fmt.Errorf("gfx: EXR channel %q has unknown pixel type %d", name, pixel_type)
+		}
+		channels = append(channels, exrChannel{name, pixel_type})
+		value = value[end+17:]
+	}
+	return channels, nil
+}
+
+func (c *Canvas) readEXRChunk(data []byte, channels []exrChannel, y0, y1 int) {
+	offset := 0
+	for y := y0; y < y1; y++ {
+		for _, ch := range channels {
+			for x := range int(c.width) {
+				var v float64
+				switch ch.pixel_type {
+				case exrUint:
+					v = float64(binary.LittleEndian.Uint32(data[offset:]))
+				case exrHalf:
+					v = halfToFloat(binary.LittleEndian.Uint16(data[offset:]))
+				default:
+					v = float64(math.Float32frombits(binary.LittleEndian.Uint32(data[offset:])))
+				}
+				offset += ch.size()
+
+				p := &c.buffer[y*int(c.width)+x]
+				switch ch.name {
+				case "R":
+					p.R = v
+				case "G":
+					p.G = v
+				case "B":
+					p.B = v
+				case "A":
+					p.A = v
+				}
+			}
+		}
+	}
+}
+
+// halfToFloat converts an IEEE 754 half precision float
+func halfToFloat(h uint16) float64 {
+	sign := 1.0
+	if h&0x8000 != 0 {
+		sign = -1
+	}
+	exponent := int(h>>10) & 0x1f
+	mantissa := float64(h & 0x3ff)
+
+	switch exponent {
+	case 0:
+		return sign * math.Ldexp(mantissa, -24)
+	case 0x1f:
+		if mantissa == 0 {
+			return math.Inf(int(sign))
+		}
+		return math.NaN()
+	}
+	return sign * math.Ldexp(1+mantissa/1024, exponent-15)
+}
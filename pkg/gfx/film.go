@@ -0,0 +1,97 @@
+package gfx
+
+import (
+	"math"
+
+	. "github.com/novelalex/soft-raytracer/pkg/nmath"
+)
+
+// Film accumulates weighted samples for a rectangle of pixels starting at
+// (X0, Y0). Each sample is splatted onto every pixel whose center is within
+// the radius of the filter, so neighbouring regions overlap and are combined with Merge.
+type Film struct {
+	X0     uint
+	Y0     uint
+	width  uint
+	height uint
+	filter Filter
+	sum    []Vec3
+	weight []float64
+}
+
+func NewFilm(width, height uint, filter Filter) Film {
+	return NewFilmRegion(0, 0, width, height, filter)
+}
+
+func NewFilmRegion(x0, y0, width, height uint, filter Filter) Film {
+	return Film{
+		x0, y0,
+		width, height,
+		filter,
+		make([]Vec3, width*height),
+		make([]float64, width*height),
+	}
+}
+
+func (f Film) Width() uint {
+	return f.width
+}
+
+func (f Film) Height() uint {
+	return f.height
+}
+
+// AddSample adds a sample taken at the continuous image position (x, y),
+// pixel (px, py) covers [px, px+1) x [py, py+1)
+func (f *Film) AddSample(x, y float64, color Color) {
+	r := f.filter.Radius()
+	c := color.AsVec3()
+
+	// pixels whose centers px+0.5 lie within r of the sample
+	x_min := max(int(math.Ceil(x-r-0.5)), int(f.X0))
+	x_max := min(int(math.Floor(x+r-0.5)), int(f.X0+f.width)-1)
+	y_min := max(int(math.Ceil(y-r-0.5)), int(f.Y0))
+	y_max := min(int(math.Floor(y+r-0.5)), int(f.Y0+f.height)-1)
+
+	for py := y_min; py <= y_max; py++ {
+		for px := x_min; px <= x_max; px++ {
+			w := f.filter.Eval(x-(float64(px)+0.5), y-(float64(py)+0.5))
+			if w == 0 {
+				continue
+			}
+			i := (uint(py)-f.Y0)*f.width + uint(px) - f.X0
+			f.sum[i] = f.sum[i].Add(c.Mult(w))
+			f.weight[i] += w
+		}
+	}
+}
+
+// Merge adds the samples of other to the pixels the two films share
+func (f *Film) Merge(other Film) {
+	x_min := max(f.X0, other.X0)
+	x_max := min(f.X0+f.width, other.X0+other.width)
+	y_min := max(f.Y0, other.Y0)
+	y_max := min(f.Y0+f.height, other.Y0+other.height)
+
+	for y := y_min; y < y_max; y++ {
+		for x := x_min; x < x_max; x++ {
+			i := (y-f.Y0)*f.width + x - f.X0
+			j := (y-other.Y0)*other.width + x - other.X0
+			f.sum[i] = f.sum[i].Add(other.sum[j])
+			f.weight[i] += other.weight[j]
+		}
+	}
+}
+
+// Canvas resolves each pixel to the weighted average of its samples,
+// pixels without any weight are black
+func (f Film) Canvas() Canvas {
+	canvas := NewCanvas(f.width, f.height)
+	for i := range f.sum {
+		if f.weight[i] <= 0 {
+			continue
+		}
+		canvas.buffer[i] = f.sum[i].Mult(1 / f.weight[i]).AsColor()
+	}
+	return canvas
+}
@@ -0,0 +1,50 @@
+package gfx_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/novelalex/soft-raytracer/pkg/gfx"
+	"github.com/novelalex/soft-raytracer/pkg/nmath"
+)
+
+var _ = Describe("Film", func() {
+	It("should average the samples in a pixel with a box filter", func() {
+		f := gfx.NewFilm(2, 2, gfx.NewBoxFilter())
+		f.AddSample(0.25, 0.25, nmath.NewColor(1, 0, 0))
+		f.AddSample(0.75, 0.75, nmath.NewColor(0, 0, 1))
+
+		c := f.Canvas()
+		Expect(c.PixelAt(0, 0).AsVec3().ApproxEq(nmath.NewVec3(0.5, 0, 0.5))).To(BeTrue())
+		Expect(c.PixelAt(1, 0).AsVec3().ApproxEq(nmath.NewVec3(0, 0, 0))).To(BeTrue())
+	})
+
+	It("should spread samples to neighbouring pixels with a wider filter", func() {
+		f := gfx.NewFilm(3, 1, gfx.NewTentFilter())
+		f.AddSample(1.5, 0.5, nmath.NewColor(1, 1, 1))
+
+		c := f.Canvas()
+		Expect(c.PixelAt(0, 0).AsVec3().ApproxEq(nmath.NewVec3(0, 0, 0))).To(BeTrue())
+		Expect(c.PixelAt(1, 0).AsVec3().ApproxEq(nmath.NewVec3(1, 1, 1))).To(BeTrue())
+
+		f.AddSample(0.9, 0.5, nmath.NewColor(0, 0, 0))
+		c = f.Canvas()
+		Expect(c.PixelAt(0, 0).AsVec3().ApproxEq(nmath.NewVec3(0, 0, 0))).To(BeTrue())
+		Expect(c.PixelAt(1, 0).R).To(BeNumerically("~", 1/1.4))
+	})
+
+	It("should merge the overlap of two regions", func() {
+		full := gfx.NewFilm(4, 1, gfx.NewTentFilter())
+		left := gfx.NewFilmRegion(0, 0, 3, 1, gfx.NewTentFilter())
+		right := gfx.NewFilmRegion(1, 0, 3, 1, gfx.NewTentFilter())
+		left.AddSample(1.8, 0.5, nmath.NewColor(1, 0, 0))
+		right.AddSample(2.2, 0.5, nmath.NewColor(0, 1, 0))
+		full.Merge(left)
+		full.Merge(right)
+
+		c := full.Canvas()
+		Expect(c.PixelAt(1, 0).AsVec3().ApproxEq(nmath.NewVec3(0.7, 0.3, 0))).To(BeTrue())
+		Expect(c.PixelAt(2, 0).AsVec3().ApproxEq(nmath.NewVec3(0.3, 0.7, 0))).To(BeTrue())
+		Expect(c.PixelAt(3, 0).AsVec3().ApproxEq(nmath.NewVec3(0, 0, 0))).To(BeTrue())
+	})
+})
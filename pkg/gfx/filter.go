@@ -0,0 +1,104 @@
+package gfx
+
+import "math"
+
+// Filter weighs a sample by its offset from the center of a pixel when
+// samples are reconstructed into pixels. Samples further than Radius away
+// on either axis don't contribute.
+type Filter interface {
+	Radius() float64
+	Eval(dx, dy float64) float64
+}
+
+// BoxFilter weighs every sample within R equally, with the default radius of
+// 0.5 each sample only counts toward the pixel it is in
+type BoxFilter struct {
+	R float64
+}
+
+func NewBoxFilter() BoxFilter {
+	return BoxFilter{0.5}
+}
+
+func (f BoxFilter) Radius() float64 {
+	return f.R
+}
+
+func (f BoxFilter) Eval(dx, dy float64) float64 {
+	if math.Abs(dx) > f.R || math.Abs(dy) > f.R {
+		return 0
+	}
+	return 1
+}
+
+// TentFilter falls off linearly to zero at R
+type TentFilter struct {
+	R float64
+}
+
+func NewTentFilter() TentFilter {
+	return TentFilter{1}
+}
+
+func (f TentFilter) Radius() float64 {
+	return f.R
+}
+
+func (f TentFilter) Eval(dx, dy float64) float64 {
+	return max(0, f.R-math.Abs(dx)) * max(0, f.R-math.Abs(dy))
+}
+
+// GaussianFilter is a gaussian with falloff Alpha, shifted down so it reaches zero at R
+type GaussianFilter struct {
+	R     float64
+	Alpha float64
+}
+
+func NewGaussianFilter() GaussianFilter {
+	return GaussianFilter{1.5, 2}
+}
+
+func (f GaussianFilter) Radius() float64 {
+	return f.R
+}
+
+func (f GaussianFilter) gaussian(d float64) float64 {
+	edge := math.Exp(-f.Alpha * f.R * f.R)
+	return max(0, math.Exp(-f.Alpha*d*d)-edge)
+}
+
+func (f GaussianFilter) Eval(dx, dy float64) float64 {
+	return f.gaussian(dx) * f.gaussian(dy)
+}
+
+// MitchellFilter is the Mitchell-Netravali cubic, B = C = 1/3 is the usual choice.
+// It has small negative lobes that sharpen edges.
+type MitchellFilter struct {
+	R float64
+	B float64
+	C float64
+}
+
+func NewMitchellFilter() MitchellFilter {
+	return MitchellFilter{2, 1.0 / 3.0, 1.0 / 3.0}
+}
+
+func (f MitchellFilter) Radius() float64 {
+	return f.R
+}
+
+// mitchell1D is defined over [-2, 2] so d is rescaled from [-R, R]
+func (f MitchellFilter) mitchell1D(d float64) float64 {
+	x := math.Abs(2 * d / f.R)
+	b, c := f.B, f.C
+	if x > 2 {
+		return 0
+	} else if x > 1 {
+		return ((-b-6*c)*x*x*x + (6*b+30*c)*x*x + (-12*b-48*c)*x + (8*b + 24*c)) / 6
+	}
+	return ((12-9*b-6*c)*x*x*x + (-18+12*b+6*c)*x*x + (6 - 2*b)) / 6
+}
+
+func (f MitchellFilter) Eval(dx, dy float64) float64 {
+	return f.mitchell1D(dx) * f.mitchell1D(dy)
+}
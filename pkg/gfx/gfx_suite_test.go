@@ -0,0 +1,13 @@
+package gfx_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestGfx(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Gfx Suite")
+}
@@ -0,0 +1,222 @@
+package gfx
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	. "github.com/novelalex/soft-raytracer/pkg/nmath"
+)
+
+// EncodeHDR writes the canvas as a Radiance RGBE file, each pixel is a
+// shared exponent and three 8 bit mantissas so it keeps the full range of
+// the render at about 1% precision. Negative channels are written as zero.
+func (c Canvas) EncodeHDR(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "#?RADIANCE\nFORMAT=32-bit_rle_rgbe\n\n-Y %d +X %d\n", c.height, c.width)
+
+	scanline := make([][4]byte, c.width)
+	for y := range c.height {
+		for x := range c.width {
+			scanline[x] = colorToRGBE(c.PixelAt(x, y))
+		}
+		if _, err := bw.Write(encodeRGBEScanline(scanline)); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// DecodeHDR reads a Radiance RGBE file with the standard -Y h +X w orientation
+func DecodeHDR(r io.Reader) (Canvas, error) {
+	br := bufio.NewReader(r)
+
+	magic, err := br.ReadString('\n')
+	if err != nil || !strings.HasPrefix(magic, "#?") {
+		return Canvas{}, errors.New("gfx: not a Radiance HDR file")
+	}
+	for {
+		line, err := br.ReadString('\n')
+		if err != nil {
+			return Canvas{}, fmt.Errorf("gfx: reading HDR header: %w", err)
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			break
+		}
+		if format, ok := strings.CutPrefix(line, "FORMAT="); ok && format != "32-bit_rle_rgbe" {
+			return Canvas{}, fmt.Errorf("gfx: unsupported HDR format %q", format)
+		}
+	}
+
+	var width, height uint
+	resolution, err := br.ReadString('\n')
+	if err != nil {
+		return Canvas{}, fmt.Errorf("gfx: reading HDR resolution: %w", err)
+	}
+	if _, err := fmt.Sscanf(resolution, "-Y %d +X %d", &height, &width); err != nil {
+		return Canvas{}, fmt.Errorf("gfx: unsupported HDR resolution %q", strings.TrimSpace(resolution))
+	}
+
+	if err := checkDecodeSize("HDR", uint64(width), uint64(height)); err != nil {
+		return Canvas{}, err
+	}
+
+	c := NewCanvas(width, height)
+	scanline := make([][4]byte, width)
+	for y := range height {
+		if err := decodeRGBEScanline(br, scanline); err != nil {
+			return Canvas{}, fmt.Errorf("gfx: reading HDR scanline %d: %w", y, err)
+		}
+		for x := range width {
+			c.WritePixel(x, y, rgbeToColor(scanline[x]))
+		}
+	}
+	return c, nil
+}
+
+func colorToRGBE(color Color) [4]byte {
+	r, g, b := max(color.R, 0), max(color.G, 0), max(color.B, 0)
+	v := max(r, g, b)
+	if v < 1e-32 {
+		return [4]byte{}
+	}
+	m, e := math.Frexp(v)
+	scale := m * 256 / v
+	return [4]byte{byte(r * scale), byte(g * scale), byte(b * scale), byte(e + 128)}
+}
+
+func rgbeToColor(rgbe [4]byte) Color {
+	if rgbe[3] == 0 {
+		return NewColor(0, 0, 0)
+	}
+	f := math.Ldexp(1, int(rgbe[3])-(128+8))
+	return NewColor(
+		float64(rgbe[0])*f,
+		float64(rgbe[1])*f,
+		float64(rgbe[2])*f,
+	)
+}
+
+// encodeRGBEScanline uses the run length encoding that stores each component
+// separately, scanlines it can't handle are written flat
+func encodeRGBEScanline(scanline [][4]byte) []byte {
+	width := len(scanline)
+	if width < 8 || width > 0x7fff {
+		result := make([]byte, 0, width*4)
+		for _, p := range scanline {
+			result = append(result, p[:]...)
+		}
+		return result
+	}
+
+	result := []byte{2, 2, byte(width >> 8), byte(width & 0xff)}
+	component := make([]byte, width)
+	for i := range 4 {
+		for x, p := range scanline {
+			component[x] = p[i]
+		}
+		result = appendRLE(result, component)
+	}
+	return result
+}
+
+// appendRLE writes runs of at least 4 equal bytes as (128+count, value) and
+// everything else as (count, bytes...)
+func appendRLE(result, data []byte) []byte {
+	const min_run = 4
+
+	for i := 0; i < len(data); {
+		// find the next run long enough to be worth encoding
+		run_start := i
+		run_length := 0
+		for run_start < len(data) {
+			run_length = 1
+			for run_start+run_length < len(data) && run_length < 127 && data[run_start+run_length] == data[run_start] {
+				run_length++
+			}
+			if run_length >= min_run {
+				break
+			}
+			run_start += run_length
+		}
+		if run_length < min_run {
+			run_start = len(data)
+		}
+
+		for i < run_start {
+			count := min(run_start-i, 128)
+			result = append(result, byte(count))
+			result = append(result, data[i:i+count]...)
+			i += count
+		}
+
+		if run_start < len(data) {
+			result = append(result, byte(128+run_length), data[run_start])
+			i = run_start + run_length
+		}
+	}
+	return result
+}
+
+func decodeRGBEScanline(r *bufio.Reader, scanline [][4]byte) error {
+	width := len(scanline)
+	var first [4]byte
+	if _, err := io.ReadFull(r, first[:]); err != nil {
+		return err
+	}
+
+	is_rle := width >= 8 && width <= 0x7fff && first[0] == 2 && first[1] == 2 && first[2]&0x80 == 0
+	if !is_rle {
+		scanline[0] = first
+		for x := 1; x < width; x++ {
+			if _, err := io.ReadFull(r, scanline[x][:]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if int(first[2])<<8|int(first[3]) != width {
+		return errors.New("scanline width doesn't match the image")
+	}
+	for i := range 4 {
+		for x := 0; x < width; {
+			count, err := r.ReadByte()
+			if err != nil {
+				return err
+			}
+			if count > 128 {
+				n := int(count) - 128
+				value, err := r.ReadByte()
+				if err != nil {
+					return err
+				}
+				if x+n > width {
+					return errors.New("run overflows the scanline")
+				}
+				for range n {
+					scanline[x][i] = value
+					x++
+				}
+			} else {
+				n := int(count)
+				if n == 0 || x+n > width {
+					return errors.New("bad run length")
+				}
+				for range n {
+					value, err := r.ReadByte()
+					if err != nil {
+						return err
+					}
+					scanline[x][i] = value
+					x++
+				}
+			}
+		}
+	}
+	return nil
+}
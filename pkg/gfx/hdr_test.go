@@ -0,0 +1,211 @@
+package gfx_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"math/rand/v2"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/novelalex/soft-raytracer/pkg/gfx"
+	"github.com/novelalex/soft-raytracer/pkg/nmath"
+)
+
+var _ = Describe("HDR formats", func() {
+	// a gradient far outside [0, 1] with some flat areas for the run length encoding
+	newCanvas := func(w, h uint) gfx.Canvas {
+		c := gfx.NewCanvas(w, h)
+		for y := range h {
+			for x := range w {
+				if x > w/2 {
+					c.WritePixel(x, y, nmath.NewColor(4, 0.5, 0))
+				} else {
+					c.WritePixel(x, y, nmath.NewColor(float64(x)*3.7, float64(y)*0.01, 1e-3*float64(x+y)))
+				}
+			}
+		}
+		return c
+	}
+
+	expectClose := func(result, expected gfx.Canvas, tolerance float64) {
+		Expect(result.Width()).To(Equal(expected.Width()))
+		Expect(result.Height()).To(Equal(expected.Height()))
+		for y := range expected.Height() {
+			for x := range expected.Width() {
+				// error is relative to the brightest channel for formats with a shared exponent
+				r, e := result.PixelAt(x, y), expected.PixelAt(x, y)
+				scale := max(e.R, e.G, e.B, 1e-3)
+				for i := range 3 {
+					Expect(r.At(i)).To(BeNumerically("~", e.At(i), tolerance*scale))
+				}
+			}
+		}
+	}
+
+	Describe("Radiance HDR", func() {
+		for _, width := range []uint{5, 40} {
+			It("should round trip", func() {
+				c := newCanvas(width, 7)
+				var buf bytes.Buffer
+				Expect(c.EncodeHDR(&buf)).To(Succeed())
+				Expect(buf.String()).To(HavePrefix("#?RADIANCE\n"))
+
+				result, err := gfx.DecodeHDR(&buf)
+				Expect(err).NotTo(HaveOccurred())
+				expectClose(result, c, 0.02)
+			})
+		}
+
+		It("should reject other files", func() {
+			_, err := gfx.DecodeHDR(bytes.NewReader([]byte("P6\n1 1\n255\n")))
+			Expect(err).To(HaveOccurred())
+		})
+
+		DescribeTable("should reject huge resolutions before allocating",
+			func(resolution string) {
+				_, err := gfx.DecodeHDR(bytes.NewReader([]byte("#?RADIANCE\nFORMAT=32-bit_rle_rgbe\n\n" + resolution + "\n")))
+				Expect(err).To(MatchError(ContainSubstring("too large")))
+			},
+			Entry("overflowing", "-Y 4294967295 +X 4294967295"),
+			Entry("large", "-Y 100000 +X 100000"),
+			Entry("one long line", "-Y 1 +X 4294967295"),
+		)
+	})
+
+	Describe("PFM", func() {
+		It("should round trip exactly for float32 values", func() {
+			c := newCanvas(9, 4)
+			var buf bytes.Buffer
+			Expect(c.EncodePFM(&buf)).To(Succeed())
+
+			result, err := gfx.DecodePFM(&buf)
+			Expect(err).NotTo(HaveOccurred())
+			expectClose(result, c, 1e-6)
+		})
+
+		It("should read big endian grayscale maps bottom to top", func() {
+			data := []byte("Pf\n1 2\n1.0\n")
+			data = binary.BigEndian.AppendUint32(data, math.Float32bits(2))
+			data = binary.BigEndian.AppendUint32(data, math.Float32bits(0.5))
+
+			c, err := gfx.DecodePFM(bytes.NewReader(data))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(c.PixelAt(0, 0)).To(Equal(nmath.NewColor(0.5, 0.5, 0.5)))
+			Expect(c.PixelAt(0, 1)).To(Equal(nmath.NewColor(2, 2, 2)))
+		})
+
+		DescribeTable("should reject huge sizes before allocating",
+			func(header string) {
+				_, err := gfx.DecodePFM(bytes.NewReader([]byte(header)))
+				Expect(err).To(MatchError(ContainSubstring("too large")))
+			},
+			Entry("overflowing", "PF\n4294967295 4294967295\n-1.0\n"),
+			Entry("large", "PF\n100000 100000\n-1.0\n"),
+			Entry("one long row", "Pf\n4294967295 0\n-1.0\n"),
+		)
+	})
+
+	Describe("OpenEXR", func() {
+		for _, compression := range []gfx.EXRCompression{gfx.EXRNoCompression, gfx.EXRZIPCompression} {
+			It("should round trip", func() {
+				c := newCanvas(33, 21)
+				var buf bytes.Buffer
+				Expect(c.EncodeEXR(&buf, compression)).To(Succeed())
+
+				result, err := gfx.DecodeEXR(&buf)
+				Expect(err).NotTo(HaveOccurred())
+				expectClose(result, c, 1e-6)
+			})
+		}
+
+		It("should compress flat images with ZIP", func() {
+			c := gfx.NewCanvas(64, 64)
+			var raw, zipped bytes.Buffer
+			Expect(c.EncodeEXR(&raw, gfx.EXRNoCompression)).To(Succeed())
+			Expect(c.EncodeEXR(&zipped, gfx.EXRZIPCompression)).To(Succeed())
+			Expect(zipped.Len()).To(BeNumerically("<", raw.Len()/10))
+		})
+
+		It("should reject other files", func() {
+			_, err := gfx.DecodeEXR(bytes.NewReader([]byte("PF\n1 1\n-1.0\n")))
+			Expect(err).To(HaveOccurred())
+		})
+
+		Describe("corrupt files", func() {
+			var file []byte
+
+			BeforeEach(func() {
+				var buf bytes.Buffer
+				Expect(newCanvas(2, 1).EncodeEXR(&buf, gfx.EXRNoCompression)).To(Succeed())
+				file = buf.Bytes()
+			})
+
+			// attribute is the offset of the size of an attribute in the header
+			attribute := func(name string) int {
+				i := bytes.Index(file, []byte(name+"\x00"))
+				Expect(i).To(BeNumerically(">", 0))
+				return i + len(name) + 1 + bytes.IndexByte(file[i+len(name)+1:], 0) + 1
+			}
+
+			decode := func() error {
+				_, err := gfx.DecodeEXR(bytes.NewReader(file))
+				return err
+			}
+
+			It("should reject deep data", func() {
+				file[5] |= 0x08
+				Expect(decode()).To(MatchError(ContainSubstring("scanline")))
+			})
+
+			It("should reject huge attributes", func() {
+				binary.LittleEndian.PutUint32(file[attribute("lineOrder"):], math.MaxUint32)
+				Expect(decode()).To(MatchError(ContainSubstring("too large")))
+			})
+
+			It("should reject an empty compression attribute", func() {
+				i := attribute("compression")
+				binary.LittleEndian.PutUint32(file[i:], 0)
+				file = append(file[:i+4], file[i+5:]...)
+				Expect(decode()).To(MatchError(ContainSubstring("compression")))
+			})
+
+			It("should reject inverted and huge data windows", func() {
+				i := attribute("dataWindow") + 4
+				binary.LittleEndian.PutUint32(file[i+8:], math.MaxUint32)
+				Expect(decode()).To(MatchError(ContainSubstring("empty")))
+
+				binary.LittleEndian.PutUint32(file[i+8:], math.MaxInt32)
+				Expect(decode()).To(MatchError(ContainSubstring("too large")))
+
+				// large enough to pass the limit, the chunks are still too small for it
+				binary.LittleEndian.PutUint32(file[i+8:], 1<<20)
+				Expect(decode()).To(MatchError(ContainSubstring("wrong size")))
+			})
+
+			It("should reject negative chunk sizes", func() {
+				// the only chunk is its line, its size and two pixels of three floats
+				binary.LittleEndian.PutUint32(file[len(file)-28:], 0xffffff00)
+				Expect(decode()).To(MatchError(ContainSubstring("wrong size")))
+			})
+
+			It("should fail instead of panicking on random corruption", func() {
+				var zipped bytes.Buffer
+				Expect(newCanvas(9, 17).EncodeEXR(&zipped, gfx.EXRZIPCompression)).To(Succeed())
+				rng := rand.New(rand.NewPCG(1, 2))
+				for _, original := range [][]byte{file, zipped.Bytes()} {
+					for range 2000 {
+						corrupt := bytes.Clone(original)
+						for range 1 + rng.IntN(4) {
+							corrupt[rng.IntN(len(corrupt))] = byte(rng.Uint32())
+						}
+						Expect(func() {
+							gfx.DecodeEXR(bytes.NewReader(corrupt))
+						}).NotTo(Panic())
+					}
+				}
+			})
+		})
+	})
+})
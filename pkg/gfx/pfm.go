@@ -0,0 +1,88 @@
+package gfx
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	. "github.com/novelalex/soft-raytracer/pkg/nmath"
+)
+
+// EncodePFM writes the canvas as a little endian color Portable Float Map,
+// every channel is stored as a 32 bit float
+func (c Canvas) EncodePFM(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "PF\n%d %d\n-1.0\n", c.width, c.height)
+
+	// rows go from the bottom of the image to the top
+	row := make([]byte, c.width*12)
+	for y := int(c.height) - 1; y >= 0; y-- {
+		for x := range c.width {
+			p := c.PixelAt(x, uint(y))
+			binary.LittleEndian.PutUint32(row[x*12:], math.Float32bits(float32(p.R)))
+			binary.LittleEndian.PutUint32(row[x*12+4:], math.Float32bits(float32(p.G)))
+			binary.LittleEndian.PutUint32(row[x*12+8:], math.Float32bits(float32(p.B)))
+		}
+		if _, err := bw.Write(row); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// DecodePFM reads a color (PF) or grayscale (Pf) Portable Float Map
+// in either byte order
+func DecodePFM(r io.Reader) (Canvas, error) {
+	br := bufio.NewReader(r)
+
+	var magic string
+	var width, height uint
+	var scale float64
+	if _, err := fmt.Fscan(br, &magic, &width, &height, &scale); err != nil {
+		return Canvas{}, fmt.Errorf("gfx: reading PFM header: %w", err)
+	}
+	channels := 0
+	switch magic {
+	case "PF":
+		channels = 3
+	case "Pf":
+		channels = 1
+	default:
+		return Canvas{}, errors.New("gfx: not a PFM file")
+	}
+	// a single whitespace character separates the header from the data
+	if _, err := br.ReadByte(); err != nil {
+		return Canvas{}, fmt.Errorf("gfx: reading PFM header: %w", err)
+	}
+
+	var order binary.ByteOrder = binary.BigEndian
+	if scale < 0 {
+		order = binary.LittleEndian
+	}
+
+	if err := checkDecodeSize("PFM", uint64(width), uint64(height)); err != nil {
+		return Canvas{}, err
+	}
+
+	c := NewCanvas(width, height)
+	row := make([]byte, width*uint(channels)*4)
+	for y := int(height) - 1; y >= 0; y-- {
+		if _, err := io.ReadFull(br, row); err != nil {
+			return Canvas{}, fmt.Errorf("gfx: reading PFM data: %w", err)
+		}
+		for x := range width {
+			var v [3]float64
+			for i := range channels {
+				v[i] = float64(math.Float32frombits(order.Uint32(row[(x*uint(channels)+uint(i))*4:])))
+			}
+			if channels == 1 {
+				v[1], v[2] = v[0], v[0]
+			}
+			c.WritePixel(x, uint(y), NewColor(v[0], v[1], v[2]))
+		}
+	}
+	return c, nil
+}
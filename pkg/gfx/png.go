@@ -0,0 +1,130 @@
+package gfx
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+
+	. "github.com/novelalex/soft-raytracer/pkg/nmath"
+)
+
+// PNGOptions picks the bit depth, 8 or 16 (zero is 8), and whether Color.A is written
+// as the alpha channel. Without it the image is opaque.
+type PNGOptions struct {
+	Depth int
+	Alpha bool
+}
+
+func DefaultPNGOptions() PNGOptions {
+	return PNGOptions{8, false}
+}
+
+// ColorModel, Bounds and At make Canvas an image.Image, channels are
+// clamped to [0, 1] and alpha is taken from Color.A
+func (c Canvas) ColorModel() color.Model {
+	return color.NRGBA64Model
+}
+
+func (c Canvas) Bounds() image.Rectangle {
+	return image.Rect(0, 0, int(c.width), int(c.height))
+}
+
+func (c Canvas) At(x, y int) color.Color {
+	if !(image.Point{x, y}.In(c.Bounds())) {
+		return color.NRGBA64{}
+	}
+	p := c.PixelAt(uint(x), uint(y))
+	return color.NRGBA64{
+		quantize16(p.R),
+		quantize16(p.G),
+		quantize16(p.B),
+		quantize16(p.A),
+	}
+}
+
+func quantize8(v float64) uint8 {
+	return uint8(math.Max(0, math.Min(math.Round(v*255), 255)))
+}
+
+func quantize16(v float64) uint16 {
+	return uint16(math.Max(0, math.Min(math.Round(v*65535), 65535)))
+}
+
+// EncodePNG writes the canvas to w as a PNG
+func (c Canvas) EncodePNG(w io.Writer, options PNGOptions) error {
+	var img image.Image
+	bounds := c.Bounds()
+
+	if options.Depth != 0 && options.Depth != 8 && options.Depth != 16 {
+		return fmt.Errorf("gfx: unsupported PNG depth %d", options.Depth)
+	}
+
+	if options.Depth == 16 {
+		if options.Alpha {
+			img = c
+		} else {
+			rgba := image.NewRGBA64(bounds)
+			for y := range c.height {
+				for x := range c.width {
+					p := c.PixelAt(x, y)
+					rgba.SetRGBA64(int(x), int(y), color.RGBA64{quantize16(p.R), quantize16(p.G), quantize16(p.B), 0xffff})
+				}
+			}
+			img = rgba
+		}
+	} else {
+		nrgba := image.NewNRGBA(bounds)
+		for y := range c.height {
+			for x := range c.width {
+				p := c.PixelAt(x, y)
+				a := uint8(0xff)
+				if options.Alpha {
+					a = quantize8(p.A)
+				}
+				nrgba.SetNRGBA(int(x), int(y), color.NRGBA{quantize8(p.R), quantize8(p.G), quantize8(p.B), a})
+			}
+		}
+		img = nrgba
+	}
+
+	return png.Encode(w, img)
+}
+
+func (c Canvas) AsPNG(options PNGOptions) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := c.EncodePNG(&buf, options); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// DecodePNG reads a PNG into a canvas, the channels keep the values stored in
+// the file scaled to [0, 1] and alpha goes to Color.A
+func DecodePNG(r io.Reader) (Canvas, error) {
+	img, err := png.Decode(r)
+	if err != nil {
+		return Canvas{}, fmt.Errorf("gfx: %w", err)
+	}
+	return canvasFromImage(img), nil
+}
+
+func canvasFromImage(img image.Image) Canvas {
+	bounds := img.Bounds()
+	c := NewCanvas(uint(bounds.Dx()), uint(bounds.Dy()))
+	for y := range c.height {
+		for x := range c.width {
+			p := color.NRGBA64Model.Convert(img.At(bounds.Min.X+int(x), bounds.Min.Y+int(y))).(color.NRGBA64)
+			c.WritePixel(x, y, Color{
+				R: float64(p.R) / 0xffff,
+				G: float64(p.G) / 0xffff,
+				B: float64(p.B) / 0xffff,
+				A: float64(p.A) / 0xffff,
+			})
+		}
+	}
+	return c
+}
@@ -0,0 +1,100 @@
+package gfx_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/novelalex/soft-raytracer/pkg/gfx"
+	"github.com/novelalex/soft-raytracer/pkg/nmath"
+)
+
+var _ = Describe("PNG", func() {
+	newCanvas := func() gfx.Canvas {
+		c := gfx.NewCanvas(3, 2)
+		c.WritePixel(0, 0, nmath.NewColor(1, 0, 0))
+		c.WritePixel(1, 0, nmath.NewColor(0, 0.5, 0))
+		c.WritePixel(2, 0, nmath.NewColor(-1, 2, 0.25))
+		c.WritePixel(0, 1, nmath.Color{R: 1, G: 1, B: 1, A: 0.5})
+		return c
+	}
+
+	decode := func(data []byte) image.Image {
+		img, err := png.Decode(bytes.NewReader(data))
+		Expect(err).NotTo(HaveOccurred())
+		return img
+	}
+
+	It("should be usable as an image.Image", func() {
+		var img image.Image = newCanvas()
+		Expect(img.Bounds()).To(Equal(image.Rect(0, 0, 3, 2)))
+		Expect(img.At(2, 0)).To(Equal(color.NRGBA64{0, 0xffff, 0x4000, 0xffff}))
+		Expect(img.At(0, 1)).To(Equal(color.NRGBA64{0xffff, 0xffff, 0xffff, 0x8000}))
+		Expect(img.At(5, 5)).To(Equal(color.NRGBA64{}))
+	})
+
+	It("should encode an opaque 8 bit image", func() {
+		data, err := newCanvas().AsPNG(gfx.DefaultPNGOptions())
+		Expect(err).NotTo(HaveOccurred())
+
+		img := decode(data)
+		Expect(img.Bounds()).To(Equal(image.Rect(0, 0, 3, 2)))
+		Expect(color.NRGBAModel.Convert(img.At(1, 0))).To(Equal(color.NRGBA{0, 128, 0, 255}))
+		Expect(color.NRGBAModel.Convert(img.At(2, 0))).To(Equal(color.NRGBA{0, 255, 64, 255}))
+		Expect(color.NRGBAModel.Convert(img.At(0, 1))).To(Equal(color.NRGBA{255, 255, 255, 255}))
+	})
+
+	It("should encode alpha when asked to", func() {
+		data, err := newCanvas().AsPNG(gfx.PNGOptions{Depth: 8, Alpha: true})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(color.NRGBAModel.Convert(decode(data).At(0, 1))).To(Equal(color.NRGBA{255, 255, 255, 128}))
+	})
+
+	It("should encode 16 bit images", func() {
+		for _, alpha := range []bool{false, true} {
+			data, err := newCanvas().AsPNG(gfx.PNGOptions{Depth: 16, Alpha: alpha})
+			Expect(err).NotTo(HaveOccurred())
+
+			img := decode(data)
+			Expect(img.ColorModel()).To(SatisfyAny(Equal(color.RGBA64Model), Equal(color.NRGBA64Model)))
+			Expect(color.NRGBA64Model.Convert(img.At(1, 0))).To(Equal(color.NRGBA64{0, 0x8000, 0, 0xffff}))
+		}
+	})
+
+	It("should refuse other depths", func() {
+		_, err := newCanvas().AsPNG(gfx.PNGOptions{Depth: 12})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should decode what it encodes", func() {
+		original := gfx.NewCanvas(2, 2)
+		original.WritePixel(0, 0, nmath.NewColor(1, 0, 0.25))
+		original.WritePixel(1, 1, nmath.Color{R: 0.5, G: 0.75, B: 1, A: 0.5})
+		data, err := original.AsPNG(gfx.PNGOptions{Depth: 16, Alpha: true})
+		Expect(err).NotTo(HaveOccurred())
+
+		decoded, err := gfx.DecodePNG(bytes.NewReader(data))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(decoded.Width()).To(Equal(original.Width()))
+		Expect(decoded.Height()).To(Equal(original.Height()))
+		for y := range original.Height() {
+			for x := range original.Width() {
+				a := decoded.PixelAt(x, y).AsVec4()
+				b := original.PixelAt(x, y).AsVec4()
+				Expect(a.X).To(BeNumerically("~", b.X, 1e-4))
+				Expect(a.Y).To(BeNumerically("~", b.Y, 1e-4))
+				Expect(a.Z).To(BeNumerically("~", b.Z, 1e-4))
+				Expect(a.W).To(BeNumerically("~", b.W, 1e-4))
+			}
+		}
+	})
+
+	It("should refuse files that aren't PNGs", func() {
+		_, err := gfx.DecodePNG(bytes.NewReader([]byte("P3\n1 1\n255\n0 0 0\n")))
+		Expect(err).To(HaveOccurred())
+	})
+})
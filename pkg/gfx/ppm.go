@@ -0,0 +1,135 @@
+package gfx
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	. "github.com/novelalex/soft-raytracer/pkg/nmath"
+)
+
+// DecodePPM reads a plain (P3) or binary (P6) PPM with any maximum value up to 65535,
+// the channels are scaled to [0, 1]
+func DecodePPM(r io.Reader) (Canvas, error) {
+	br := bufio.NewReader(r)
+
+	magic, err := readPPMToken(br)
+	if err != nil || (magic != "P3" && magic != "P6") {
+		return Canvas{}, errors.New("gfx: not a P3 or P6 PPM file")
+	}
+
+	var header [3]int
+	for i := range header {
+		token, err := readPPMToken(br)
+		if err != nil {
+			return Canvas{}, fmt.Errorf("gfx: reading PPM header: %w", err)
+		}
+		header[i], err = strconv.Atoi(token)
+		if err != nil || header[i] <= 0 {
+			return Canvas{}, fmt.Errorf("gfx: bad PPM header value %q", token)
+		}
+	}
+	width, height, max_value := header[0], header[1], header[2]
+	if max_value > 65535 {
+		return Canvas{}, fmt.Errorf("gfx: PPM maximum value %d is too large", max_value)
+	}
+
+	// the limit also bounds the row of binary samples read below
+	if err := checkDecodeSize("PPM", uint64(width), uint64(height)); err != nil {
+		return Canvas{}, err
+	}
+
+	c := NewCanvas(uint(width), uint(height))
+	scale := 1 / float64(max_value)
+
+	if magic == "P3" {
+		for i := range c.buffer {
+			var v [3]float64
+			for j := range v {
+				token, err := readPPMToken(br)
+				if err != nil {
+					return Canvas{}, fmt.Errorf("gfx: reading PPM pixels: %w", err)
+				}
+				n, err := strconv.Atoi(token)
+				if err != nil || n < 0 || n > max_value {
+					return Canvas{}, fmt.Errorf("gfx: bad PPM sample %q", token)
+				}
+				v[j] = float64(n) * scale
+			}
+			c.buffer[i] = NewColor(v[0], v[1], v[2])
+		}
+		return c, nil
+	}
+
+	// reading the maximum value consumed the single whitespace
+	// character that separates the header from the binary samples
+	sample_size := 1
+	if max_value > 255 {
+		sample_size = 2
+	}
+	row := make([]byte, width*3*sample_size)
+	for y := range height {
+		if _, err := io.ReadFull(br, row); err != nil {
+			return Canvas{}, fmt.Errorf("gfx: reading PPM pixels: %w", err)
+		}
+		for x := range width {
+			var v [3]float64
+			for j := range v {
+				k := (x*3 + j) * sample_size
+				n := int(row[k])
+				if sample_size == 2 {
+					n = n<<8 | int(row[k+1])
+				}
+				v[j] = float64(n) * scale
+			}
+			c.buffer[y*width+x] = NewColor(v[0], v[1], v[2])
+		}
+	}
+	return c, nil
+}
+
+// readPPMToken reads the next whitespace separated token, skipping # comments
+func readPPMToken(br *bufio.Reader) (string, error) {
+	var token []byte
+	for {
+		b, err := br.ReadByte()
+		if err == io.EOF && len(token) > 0 {
+			return string(token), nil
+		}
+		if err != nil {
+			return "", err
+		}
+
+		switch {
+		case b == '#' && len(token) == 0:
+			if _, err := br.ReadBytes('\n'); err != nil && err != io.EOF {
+				return "", err
+			}
+		case bytes.IndexByte([]byte(" \t\r\n\v\f"), b) >= 0:
+			if len(token) > 0 {
+				return string(token), nil
+			}
+		default:
+			token = append(token, b)
+		}
+	}
+}
+
+// DecodeImage reads a PNG or PPM, telling them apart by their first bytes
+func DecodeImage(r io.Reader) (Canvas, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(2)
+	if err != nil {
+		return Canvas{}, fmt.Errorf("gfx: reading image: %w", err)
+	}
+	switch {
+	case magic[0] == 0x89 && magic[1] == 'P':
+		return DecodePNG(br)
+	case magic[0] == 'P' && (magic[1] == '3' || magic[1] == '6'):
+		return DecodePPM(br)
+	}
+	return Canvas{}, errors.New("gfx: image is neither a PNG nor a PPM")
+}
@@ -0,0 +1,95 @@
+package gfx_test
+
+import (
+	"bytes"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/novelalex/soft-raytracer/pkg/gfx"
+	"github.com/novelalex/soft-raytracer/pkg/nmath"
+)
+
+var _ = Describe("PPM", func() {
+	newCanvas := func() gfx.Canvas {
+		c := gfx.NewCanvas(3, 2)
+		c.WritePixel(0, 0, nmath.NewColor(1, 0, 0))
+		c.WritePixel(1, 0, nmath.NewColor(0, 1, 0))
+		c.WritePixel(2, 1, nmath.NewColor(0.2, 0.4, 1))
+		return c
+	}
+
+	expectClose := func(a, b gfx.Canvas) {
+		Expect(a.Width()).To(Equal(b.Width()))
+		Expect(a.Height()).To(Equal(b.Height()))
+		for y := range a.Height() {
+			for x := range a.Width() {
+				for i := range 3 {
+					Expect(a.PixelAt(x, y).At(i)).To(BeNumerically("~", b.PixelAt(x, y).At(i), 0.5/255))
+				}
+			}
+		}
+	}
+
+	It("should decode plain PPMs", func() {
+		c, err := gfx.DecodePPM(strings.NewReader(newCanvas().AsPPM()))
+		Expect(err).NotTo(HaveOccurred())
+		expectClose(c, newCanvas())
+	})
+
+	It("should decode binary PPMs", func() {
+		c, err := gfx.DecodePPM(bytes.NewReader(newCanvas().AsP6PPM()))
+		Expect(err).NotTo(HaveOccurred())
+		expectClose(c, newCanvas())
+	})
+
+	It("should skip comments and scale by the maximum value", func() {
+		c, err := gfx.DecodePPM(strings.NewReader("P3\n# made by hand\n2 1 # size\n10\n10 5 0  0 0 10\n"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(c.PixelAt(0, 0)).To(Equal(nmath.NewColor(1, 0.5, 0)))
+		Expect(c.PixelAt(1, 0)).To(Equal(nmath.NewColor(0, 0, 1)))
+	})
+
+	It("should decode 16 bit binary PPMs", func() {
+		data := append([]byte("P6 1 1 65535\n"), 0xff, 0xff, 0x80, 0x00, 0x00, 0x00)
+		c, err := gfx.DecodePPM(bytes.NewReader(data))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(c.PixelAt(0, 0).R).To(Equal(1.0))
+		Expect(c.PixelAt(0, 0).G).To(BeNumerically("~", 0.5, 1e-4))
+		Expect(c.PixelAt(0, 0).B).To(Equal(0.0))
+	})
+
+	It("should refuse truncated files", func() {
+		_, err := gfx.DecodePPM(strings.NewReader("P3\n2 2\n255\n0 0 0\n"))
+		Expect(err).To(HaveOccurred())
+		_, err = gfx.DecodePPM(bytes.NewReader([]byte("P6\n2 2\n255\n\x00\x00")))
+		Expect(err).To(HaveOccurred())
+	})
+
+	DescribeTable("should refuse huge sizes before allocating",
+		func(header string) {
+			_, err := gfx.DecodePPM(strings.NewReader(header))
+			Expect(err).To(MatchError(ContainSubstring("too large")))
+			_, err = gfx.DecodeImage(strings.NewReader(header))
+			Expect(err).To(MatchError(ContainSubstring("too large")))
+		},
+		Entry("overflowing", "P6\n4294967295 4294967295\n255\n"),
+		Entry("large", "P3\n100000 100000\n255\n"),
+		Entry("one long row", "P6\n4294967295 1\n65535\n"),
+	)
+
+	It("should tell PNGs and PPMs apart", func() {
+		png, err := newCanvas().AsPNG(gfx.DefaultPNGOptions())
+		Expect(err).NotTo(HaveOccurred())
+
+		for _, data := range [][]byte{png, newCanvas().AsP6PPM(), []byte(newCanvas().AsPPM())} {
+			c, err := gfx.DecodeImage(bytes.NewReader(data))
+			Expect(err).NotTo(HaveOccurred())
+			expectClose(c, newCanvas())
+		}
+
+		_, err = gfx.DecodeImage(strings.NewReader("GIF89a"))
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,153 @@
+package gfx
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+
+	. "github.com/novelalex/soft-raytracer/pkg/nmath"
+)
+
+// ToneMapper compresses the unbounded linear channels of a render into [0, 1]
+type ToneMapper interface {
+	Map(v float64) float64
+}
+
+// Clamp cuts off everything above 1, which is what the encoders do anyway
+type Clamp struct{}
+
+func (Clamp) Map(v float64) float64 {
+	return math.Max(0, math.Min(v, 1))
+}
+
+// Reinhard is v / (1 + v), it never quite reaches white
+type Reinhard struct{}
+
+func (Reinhard) Map(v float64) float64 {
+	v = math.Max(v, 0)
+	return v / (1 + v)
+}
+
+// ExtendedReinhard is Reinhard scaled so WhitePoint maps to 1
+type ExtendedReinhard struct {
+	// WhitePoint is 4 if zero or negative
+	WhitePoint float64
+}
+
+func (r ExtendedReinhard) Map(v float64) float64 {
+	white := r.WhitePoint
+	if white <= 0 {
+		white = 4
+	}
+	v = math.Max(v, 0)
+	return math.Min(v*(1+v/(white*white))/(1+v), 1)
+}
+
+// ACES is Krzysztof Narkowicz's fit of the ACES filmic curve
+type ACES struct{}
+
+func (ACES) Map(v float64) float64 {
+	v = math.Max(v, 0)
+	const a, b, c, d, e = 2.51, 0.03, 2.43, 0.59, 0.14
+	return math.Max(0, math.Min((v*(a*v+b))/(v*(c*v+d)+e), 1))
+}
+
+// Uncharted2 is John Hable's filmic curve, normalized so WhitePoint maps to 1
+type Uncharted2 struct {
+	// WhitePoint is 11.2 if zero or negative
+	WhitePoint float64
+}
+
+func NewUncharted2() Uncharted2 {
+	return Uncharted2{11.2}
+}
+
+func uncharted2Curve(x float64) float64 {
+	const a, b, c, d, e, f = 0.15, 0.50, 0.10, 0.20, 0.02, 0.30
+	return ((x*(a*x+c*b) + d*e) / (x*(a*x+b) + d*f)) - e/f
+}
+
+func (u Uncharted2) Map(v float64) float64 {
+	// the curve is usually applied to twice the exposure
+	white := u.WhitePoint
+	if white <= 0 {
+		white = 11.2
+	}
+	v = math.Max(v, 0)
+	return math.Min(uncharted2Curve(2*v)/uncharted2Curve(white), 1)
+}
+
+// ParseToneMapper looks up a tone mapper by the name used on the command line
+func ParseToneMapper(name string) (ToneMapper, error) {
+	switch name {
+	case "", "none":
+		return nil, nil
+	case "clamp":
+		return Clamp{}, nil
+	case "reinhard":
+		return Reinhard{}, nil
+	case "reinhard-extended":
+		return ExtendedReinhard{4}, nil
+	case "aces":
+		return ACES{}, nil
+	case "uncharted2":
+		return NewUncharted2(), nil
+	}
+	return nil, fmt.Errorf("gfx: unknown tone mapper %q", name)
+}
+
+// LinearToSRGB applies the sRGB transfer function to a linear value in [0, 1]
+func LinearToSRGB(v float64) float64 {
+	if v <= 0.0031308 {
+		return 12.92 * v
+	}
+	return 1.055*math.Pow(v, 1/2.4) - 0.055
+}
+
+// SRGBToLinear undoes LinearToSRGB
+func SRGBToLinear(v float64) float64 {
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+// PostProcess turns the linear radiance of a render into display values,
+// it goes between Camera.Render and the 8 or 16 bit encoders. The zero value
+// leaves the canvas as it is.
+type PostProcess struct {
+	// Exposure in stops, each one doubles the brightness
+	Exposure float64
+	// ToneMapper is applied to each channel after exposure, nil to skip it
+	ToneMapper ToneMapper
+	// SRGB encodes the result with the sRGB transfer function
+	SRGB bool
+	// Dither adds up to one 8 bit step of triangular noise so gradients don't band
+	Dither bool
+	// Seed for the dither noise
+	Seed uint64
+}
+
+func (p PostProcess) Apply(c Canvas) Canvas {
+	result := NewCanvas(c.width, c.height)
+	scale := math.Exp2(p.Exposure)
+	rng := rand.New(rand.NewPCG(p.Seed, 0))
+
+	for i, color := range c.buffer {
+		var v [3]float64
+		for j := range 3 {
+			v[j] = color.At(j) * scale
+			if p.ToneMapper != nil {
+				v[j] = p.ToneMapper.Map(v[j])
+			}
+			if p.SRGB {
+				v[j] = LinearToSRGB(math.Max(0, math.Min(v[j], 1)))
+			}
+			if p.Dither {
+				v[j] += (rng.Float64() - rng.Float64()) / 255
+			}
+		}
+		result.buffer[i] = Color{R: v[0], G: v[1], B: v[2], A: color.A}
+	}
+	return result
+}
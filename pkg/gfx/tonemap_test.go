@@ -0,0 +1,89 @@
+package gfx_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/novelalex/soft-raytracer/pkg/gfx"
+	"github.com/novelalex/soft-raytracer/pkg/nmath"
+)
+
+var _ = Describe("Tone mapping", func() {
+	mappers := map[string]gfx.ToneMapper{
+		"clamp":             gfx.Clamp{},
+		"reinhard":          gfx.Reinhard{},
+		"reinhard-extended": gfx.ExtendedReinhard{WhitePoint: 4},
+		"aces":              gfx.ACES{},
+		"uncharted2":        gfx.NewUncharted2(),
+	}
+
+	for name, m := range mappers {
+		It("should keep "+name+" in range and increasing", func() {
+			Expect(m.Map(0)).To(BeNumerically("~", 0, 1e-3))
+			previous := -1.0
+			for v := 0.0; v < 20; v += 0.25 {
+				mapped := m.Map(v)
+				Expect(mapped).To(BeNumerically(">=", previous))
+				Expect(mapped).To(BeNumerically("<=", 1))
+				previous = mapped
+			}
+		})
+
+		It("should look up "+name+" by name", func() {
+			parsed, err := gfx.ParseToneMapper(name)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(parsed).To(Equal(m))
+		})
+	}
+
+	It("should reject unknown tone mappers", func() {
+		_, err := gfx.ParseToneMapper("filmic")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should map the white point to white", func() {
+		Expect(gfx.ExtendedReinhard{WhitePoint: 4}.Map(4)).To(BeNumerically("~", 1))
+		Expect(gfx.NewUncharted2().Map(5.6)).To(BeNumerically("~", 1))
+	})
+
+	It("should use the default white points for zero values", func() {
+		for _, v := range []float64{0, 0.18, 1, 3} {
+			Expect(gfx.ExtendedReinhard{}.Map(v)).To(Equal(gfx.ExtendedReinhard{WhitePoint: 4}.Map(v)))
+			Expect(gfx.Uncharted2{}.Map(v)).To(Equal(gfx.NewUncharted2().Map(v)))
+		}
+		Expect(gfx.ExtendedReinhard{}.Map(0)).To(Equal(0.0))
+		Expect(gfx.Uncharted2{}.Map(0)).To(BeNumerically("~", 0, 1e-12))
+	})
+
+	It("should round trip the sRGB transfer function", func() {
+		for v := 0.0; v <= 1; v += 0.05 {
+			Expect(gfx.SRGBToLinear(gfx.LinearToSRGB(v))).To(BeNumerically("~", v, 1e-9))
+		}
+		Expect(gfx.LinearToSRGB(0.5)).To(BeNumerically("~", 0.7354, 1e-4))
+	})
+
+	Describe("PostProcess", func() {
+		c := gfx.NewCanvas(2, 1)
+		c.WritePixel(0, 0, nmath.NewColor(0.25, 1, 3))
+		c.WritePixel(1, 0, nmath.NewColor(0.5, 0.5, 0.5))
+
+		It("should leave the canvas alone when it is zero", func() {
+			result := gfx.PostProcess{}.Apply(c)
+			Expect(result.PixelAt(0, 0)).To(Equal(c.PixelAt(0, 0)))
+		})
+
+		It("should apply exposure, tone mapping and sRGB in order", func() {
+			result := gfx.PostProcess{Exposure: 1, ToneMapper: gfx.Reinhard{}, SRGB: true}.Apply(c)
+			Expect(result.PixelAt(1, 0).R).To(BeNumerically("~", gfx.LinearToSRGB(0.5)))
+			Expect(result.PixelAt(0, 0).B).To(BeNumerically("~", gfx.LinearToSRGB(6.0/7.0)))
+		})
+
+		It("should dither by less than one 8 bit step and the same way for a seed", func() {
+			p := gfx.PostProcess{Dither: true, Seed: 9}
+			a := p.Apply(c)
+			b := p.Apply(c)
+			Expect(a.PixelAt(1, 0)).To(Equal(b.PixelAt(1, 0)))
+			Expect(a.PixelAt(1, 0).G).To(BeNumerically("~", 0.5, 1.0/255))
+		})
+	})
+})
@@ -1,6 +1,9 @@
 package nmath
 
-import "log"
+import (
+	"log"
+	"math"
+)
 
 // Mat4 is a 4D row major Matrix indexed like so:
 // ```
@@ -94,6 +97,20 @@ func (m Mat4) Transpose() Mat4 {
 	}
 }
 
+// Determinant is expanded along the first row
+func (m Mat4) Determinant() float64 {
+	return m[0]*(m[5]*m[10]*m[15]-m[5]*m[11]*m[14]-m[9]*m[6]*m[15]+m[9]*m[7]*m[14]+m[13]*m[6]*m[11]-m[13]*m[7]*m[10]) +
+		m[1]*(-m[4]*m[10]*m[15]+m[4]*m[11]*m[14]+m[8]*m[6]*m[15]-m[8]*m[7]*m[14]-m[12]*m[6]*m[11]+m[12]*m[7]*m[10]) +
+		m[2]*(m[4]*m[9]*m[15]-m[4]*m[11]*m[13]-m[8]*m[5]*m[15]+m[8]*m[7]*m[13]+m[12]*m[5]*m[11]-m[12]*m[7]*m[9]) +
+		m[3]*(-m[4]*m[9]*m[14]+m[4]*m[10]*m[13]+m[8]*m[5]*m[14]-m[8]*m[6]*m[13]-m[12]*m[5]*m[10]+m[12]*m[6]*m[9])
+}
+
+// Invertible is false for the matrices Inverse panics on, and for ones holding NaN
+func (m Mat4) Invertible() bool {
+	det := m.Determinant()
+	return !math.IsNaN(det) && !ApproxEq(det, 0.0)
+}
+
 func (m Mat4) Inverse() Mat4 {
 	adj := Mat4{
 		// Row 0
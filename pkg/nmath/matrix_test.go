@@ -147,6 +147,21 @@ var _ = Describe("Matrix", func() {
 		})
 	})
 
+	Describe("Determinant", func() {
+		It("should tell invertible matrices from singular ones", func() {
+			m := Mat4{
+				-5, 2, 6, -8,
+				1, -5, 1, 8,
+				7, 7, -6, -7,
+				1, -3, 7, 4,
+			}
+			Expect(ApproxEq(m.Determinant(), 532)).To(BeTrue())
+			Expect(m.Invertible()).To(BeTrue())
+			Expect(NewScaling(0, 1, 1).Invertible()).To(BeFalse())
+			Expect(Mat4{}.Invertible()).To(BeFalse())
+		})
+	})
+
 	Describe("Inverse", func() {
 		Context("when matrix is inverted", func() {
 			It("should return the inverse matrix", func() {
@@ -0,0 +1,49 @@
+package nmath
+
+// Transform is a Mat4 stored with its inverse and inverse transpose so they
+// only have to be computed once when the matrix changes, not for every ray.
+// Build one with NewTransform, the zero value isn't invertible.
+type Transform struct {
+	matrix            Mat4
+	inverse           Mat4
+	inverse_transpose Mat4
+}
+
+func NewTransform(m Mat4) Transform {
+	inv := m.Inverse()
+	return Transform{
+		m,
+		inv,
+		inv.Transpose(),
+	}
+}
+
+func IdentityTransform() Transform {
+	return Transform{
+		Mat4Identity(),
+		Mat4Identity(),
+		Mat4Identity(),
+	}
+}
+
+func (t Transform) Matrix() Mat4 {
+	return t.matrix
+}
+
+func (t Transform) Inverse() Mat4 {
+	return t.inverse
+}
+
+// InverseTranspose is used to move normals from object to world space
+func (t Transform) InverseTranspose() Mat4 {
+	return t.inverse_transpose
+}
+
+// Mult returns the matrix multiplied by m, wrap it in NewTransform to cache it
+func (t Transform) Mult(m Mat4) Mat4 {
+	return t.matrix.Mult(m)
+}
+
+func (t Transform) ApproxEq(other Transform) bool {
+	return t.matrix.ApproxEq(other.matrix)
+}
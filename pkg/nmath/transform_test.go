@@ -0,0 +1,42 @@
+package nmath_test
+
+import (
+	"math"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	. "github.com/novelalex/soft-raytracer/pkg/nmath"
+)
+
+var _ = Describe("Transform", func() {
+	Describe("NewTransform", func() {
+		It("should cache the inverse and inverse transpose", func() {
+			m := Mat4Identity().
+				RotateX(math.Pi/3.0).
+				Scale(2, 3, 4).
+				Translate(10, 5, 7)
+
+			t := NewTransform(m)
+
+			Expect(t.Matrix().ApproxEq(m)).To(BeTrue())
+			Expect(t.Inverse().ApproxEq(m.Inverse())).To(BeTrue())
+			Expect(t.InverseTranspose().ApproxEq(m.Inverse().Transpose())).To(BeTrue())
+		})
+	})
+
+	Describe("IdentityTransform", func() {
+		It("should match an identity matrix", func() {
+			Expect(IdentityTransform().ApproxEq(NewTransform(Mat4Identity()))).To(BeTrue())
+			Expect(IdentityTransform().Inverse().ApproxEq(Mat4Identity())).To(BeTrue())
+		})
+	})
+
+	Describe("Mult", func() {
+		It("should return the multiplied matrix", func() {
+			t := NewTransform(NewTranslation(1, 2, 3))
+			result := t.Mult(NewScaling(2, 2, 2))
+			Expect(result.ApproxEq(NewTranslation(1, 2, 3).Scale(2, 2, 2))).To(BeTrue())
+		})
+	})
+})
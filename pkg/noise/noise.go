@@ -0,0 +1,195 @@
+// Package noise implements seeded gradient noise in 3D for procedural patterns.
+package noise
+
+import (
+	"math"
+	"math/rand/v2"
+
+	"github.com/novelalex/soft-raytracer/pkg/nmath"
+)
+
+// Noise is a smooth random function of space with values in about [-1, 1],
+// it is zero on average and always gives the same value at the same point
+type Noise interface {
+	At(p nmath.Vec3) float64
+}
+
+// permutation is a shuffled table of 0 to 255, repeated so lookups
+// of the sum of two entries don't have to wrap
+type permutation [512]uint8
+
+func newPermutation(seed uint64) *permutation {
+	rng := rand.New(rand.NewPCG(seed, 0x853c49e6748fea9b))
+	var p permutation
+	for i := range 256 {
+		p[i] = uint8(i)
+	}
+	rng.Shuffle(256, func(i, j int) {
+		p[i], p[j] = p[j], p[i]
+	})
+	copy(p[256:], p[:256])
+	return &p
+}
+
+func (p *permutation) hash(x, y, z int) int {
+	return int(p[int(p[int(p[x&255])+y&255])+z&255])
+}
+
+// gradients are the 12 directions to the edges of a cube,
+// hashed lattice points pick one of them
+var gradients = [12][3]float64{
+	{1, 1, 0}, {-1, 1, 0}, {1, -1, 0}, {-1, -1, 0},
+	{1, 0, 1}, {-1, 0, 1}, {1, 0, -1}, {-1, 0, -1},
+	{0, 1, 1}, {0, -1, 1}, {0, 1, -1}, {0, -1, -1},
+}
+
+func gradientDot(hash int, x, y, z float64) float64 {
+	g := gradients[hash%12]
+	return g[0]*x + g[1]*y + g[2]*z
+}
+
+// Perlin is Ken Perlin's improved noise, it is zero at every integer point
+type Perlin struct {
+	perm *permutation
+}
+
+func NewPerlin(seed uint64) Perlin {
+	return Perlin{newPermutation(seed)}
+}
+
+func (n Perlin) At(p nmath.Vec3) float64 {
+	x0, y0, z0 := math.Floor(p.X), math.Floor(p.Y), math.Floor(p.Z)
+	x, y, z := p.X-x0, p.Y-y0, p.Z-z0
+	ix, iy, iz := int(x0), int(y0), int(z0)
+	u, v, w := fade(x), fade(y), fade(z)
+
+	corner := func(dx, dy, dz int) float64 {
+		h := n.perm.hash(ix+dx, iy+dy, iz+dz)
+		return gradientDot(h, x-float64(dx), y-float64(dy), z-float64(dz))
+	}
+
+	return lerp(w,
+		lerp(v,
+			lerp(u, corner(0, 0, 0), corner(1, 0, 0)),
+			lerp(u, corner(0, 1, 0), corner(1, 1, 0))),
+		lerp(v,
+			lerp(u, corner(0, 0, 1), corner(1, 0, 1)),
+			lerp(u, corner(0, 1, 1), corner(1, 1, 1))))
+}
+
+// fade is the quintic 6t^5 - 15t^4 + 10t^3, flat at both ends
+// so the noise has no creases at the lattice
+func fade(t float64) float64 {
+	return t * t * t * (t*(t*6-15) + 10)
+}
+
+func lerp(t, a, b float64) float64 {
+	return a + t*(b-a)
+}
+
+// Simplex is Perlin's simplex noise, it sums four corners of a tetrahedron
+// instead of the eight of a cube and has no visible grid alignment
+type Simplex struct {
+	perm *permutation
+}
+
+func NewSimplex(seed uint64) Simplex {
+	return Simplex{newPermutation(seed)}
+}
+
+const (
+	skew3   = 1.0 / 3
+	unskew3 = 1.0 / 6
+)
+
+func (n Simplex) At(p nmath.Vec3) float64 {
+	// find the cell of the skewed grid and the position inside it
+	s := (p.X + p.Y + p.Z) * skew3
+	i, j, k := math.Floor(p.X+s), math.Floor(p.Y+s), math.Floor(p.Z+s)
+	t := (i + j + k) * unskew3
+	x0, y0, z0 := p.X-(i-t), p.Y-(j-t), p.Z-(k-t)
+
+	// the order of the offsets picks which of the six tetrahedra in the cell p is in
+	var i1, j1, k1, i2, j2, k2 int
+	switch {
+	case x0 >= y0 && y0 >= z0:
+		i1, j1, k1, i2, j2, k2 = 1, 0, 0, 1, 1, 0
+	case x0 >= y0 && x0 >= z0:
+		i1, j1, k1, i2, j2, k2 = 1, 0, 0, 1, 0, 1
+	case x0 >= y0:
+		i1, j1, k1, i2, j2, k2 = 0, 0, 1, 1, 0, 1
+	case y0 < z0:
+		i1, j1, k1, i2, j2, k2 = 0, 0, 1, 0, 1, 1
+	case x0 < z0:
+		i1, j1, k1, i2, j2, k2 = 0, 1, 0, 0, 1, 1
+	default:
+		i1, j1, k1, i2, j2, k2 = 0, 1, 0, 1, 1, 0
+	}
+
+	ii, jj, kk := int(i), int(j), int(k)
+	corner := func(di, dj, dk int) float64 {
+		offset := float64(di+dj+dk) * unskew3
+		x := x0 - float64(di) + offset
+		y := y0 - float64(dj) + offset
+		z := z0 - float64(dk) + offset
+		falloff := 0.6 - x*x - y*y - z*z
+		if falloff <= 0 {
+			return 0
+		}
+		falloff *= falloff
+		return falloff * falloff * gradientDot(n.perm.hash(ii+di, jj+dj, kk+dk), x, y, z)
+	}
+
+	// scaled so the result is within [-1, 1]
+	return 32 * (corner(0, 0, 0) + corner(i1, j1, k1) + corner(i2, j2, k2) + corner(1, 1, 1))
+}
+
+// Fractal sums octaves of Source, each one at a higher frequency and a lower
+// amplitude than the one before, for detail at every scale
+type Fractal struct {
+	Source Noise
+	// Octaves is the number of layers, 4 if zero
+	Octaves int
+	// Lacunarity multiplies the frequency of each octave, 2 if zero
+	Lacunarity float64
+	// Gain multiplies the amplitude of each octave, 0.5 if zero
+	Gain float64
+}
+
+func (f Fractal) params() (int, float64, float64) {
+	octaves, lacunarity, gain := f.Octaves, f.Lacunarity, f.Gain
+	if octaves <= 0 {
+		octaves = 4
+	}
+	if lacunarity == 0 {
+		lacunarity = 2
+	}
+	if gain == 0 {
+		gain = 0.5
+	}
+	return octaves, lacunarity, gain
+}
+
+// At is fractal Brownian motion, the sum of the octaves normalized to about [-1, 1]
+func (f Fractal) At(p nmath.Vec3) float64 {
+	return f.sum(p, func(v float64) float64 { return v })
+}
+
+// Turbulence sums the absolute value of each octave instead, which leaves creases
+// where the noise crosses zero. It is in about [0, 1].
+func (f Fractal) Turbulence(p nmath.Vec3) float64 {
+	return f.sum(p, math.Abs)
+}
+
+func (f Fractal) sum(p nmath.Vec3, shape func(float64) float64) float64 {
+	octaves, lacunarity, gain := f.params()
+	total, norm := 0.0, 0.0
+	frequency, amplitude := 1.0, 1.0
+	for range octaves {
+		total += amplitude * shape(f.Source.At(p.Mult(frequency)))
+		norm += amplitude
+		frequency *= lacunarity
+		amplitude *= gain
+	}
+	return total / norm
+}
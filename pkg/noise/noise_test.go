@@ -0,0 +1,97 @@
+package noise_test
+
+import (
+	"math"
+	"math/rand/v2"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/novelalex/soft-raytracer/pkg/nmath"
+	"github.com/novelalex/soft-raytracer/pkg/noise"
+)
+
+var _ = Describe("Noise", func() {
+	// points spread over a few lattice cells, the same every run
+	points := func() []nmath.Vec3 {
+		rng := rand.New(rand.NewPCG(3, 4))
+		result := make([]nmath.Vec3, 2000)
+		for i := range result {
+			result[i] = nmath.NewVec3(rng.Float64()*20-10, rng.Float64()*20-10, rng.Float64()*20-10)
+		}
+		return result
+	}
+
+	sources := map[string]func(seed uint64) noise.Noise{
+		"perlin":  func(seed uint64) noise.Noise { return noise.NewPerlin(seed) },
+		"simplex": func(seed uint64) noise.Noise { return noise.NewSimplex(seed) },
+		"fbm":     func(seed uint64) noise.Noise { return noise.Fractal{Source: noise.NewPerlin(seed)} },
+	}
+
+	for name, newNoise := range sources {
+		It("should make "+name+" noise the same for a seed", func() {
+			a, b := newNoise(7), newNoise(7)
+			for _, p := range points() {
+				Expect(a.At(p)).To(Equal(b.At(p)))
+			}
+		})
+
+		It("should make different "+name+" noise for different seeds", func() {
+			a, b := newNoise(7), newNoise(8)
+			differ := 0
+			for _, p := range points() {
+				if a.At(p) != b.At(p) {
+					differ++
+				}
+			}
+			Expect(differ).To(BeNumerically(">", 1900))
+		})
+
+		It("should keep "+name+" noise within [-1, 1] and around zero", func() {
+			n := newNoise(1)
+			sum, largest := 0.0, 0.0
+			for _, p := range points() {
+				v := n.At(p)
+				Expect(math.Abs(v)).To(BeNumerically("<=", 1))
+				sum += v
+				largest = max(largest, math.Abs(v))
+			}
+			Expect(sum / 2000).To(BeNumerically("~", 0, 0.05))
+			Expect(largest).To(BeNumerically(">", 0.3))
+		})
+
+		It("should make "+name+" noise continuous", func() {
+			n := newNoise(1)
+			for _, p := range points() {
+				q := p.Add(nmath.NewVec3(1e-6, -1e-6, 1e-6))
+				Expect(n.At(p)).To(BeNumerically("~", n.At(q), 1e-4))
+			}
+		})
+	}
+
+	It("should make Perlin noise zero at integer points", func() {
+		n := noise.NewPerlin(5)
+		for x := -3; x <= 3; x++ {
+			for z := -3; z <= 3; z++ {
+				Expect(n.At(nmath.NewVec3(float64(x), 2, float64(z)))).To(Equal(0.0))
+			}
+		}
+	})
+
+	It("should keep turbulence positive", func() {
+		f := noise.Fractal{Source: noise.NewSimplex(2), Octaves: 6}
+		for _, p := range points() {
+			v := f.Turbulence(p)
+			Expect(v).To(BeNumerically(">=", 0))
+			Expect(v).To(BeNumerically("<=", 1))
+		}
+	})
+
+	It("should add octaves of finer detail", func() {
+		source := noise.NewPerlin(9)
+		p := nmath.NewVec3(0.3, 1.7, -2.2)
+		f := noise.Fractal{Source: source, Octaves: 2, Lacunarity: 3, Gain: 0.25}
+		expected := (source.At(p) + 0.25*source.At(p.Mult(3))) / 1.25
+		Expect(f.At(p)).To(BeNumerically("~", expected, 1e-12))
+	})
+})
@@ -0,0 +1,260 @@
+// Package obj parses Wavefront OBJ meshes into triangles.
+package obj
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/novelalex/soft-raytracer/pkg/geom"
+	"github.com/novelalex/soft-raytracer/pkg/nmath"
+)
+
+// File is a parsed OBJ file. Vertices, Normals and TexCoords are stored
+// zero based, the 1 based indices of the file have already been resolved.
+type File struct {
+	Vertices  []nmath.Vec3
+	Normals   []nmath.Vec3
+	TexCoords []nmath.Vec3
+	// Groups are in the order they first appear, faces before the first
+	// group statement go in a group named "" which is always Groups[0]
+	Groups []Group
+	// Ignored lists the lines that were valid but not understood by the parser
+	Ignored []Line
+}
+
+type Group struct {
+	Name      string
+	Triangles []geom.Shape
+}
+
+type Line struct {
+	Number int
+	Text   string
+}
+
+// ParseError is returned for lines that can't be parsed
+type ParseError struct {
+	Line int
+	Text string
+	Msg  string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("obj: line %d: %s: %q", e.Line, e.Msg, e.Text)
+}
+
+type faceVertex struct {
+	v, vt, vn int // -1 when missing
+}
+
+func ParseFile(path string) (*File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+func Parse(r io.Reader) (*File, error) {
+	f := &File{
+		Groups: []Group{{Name: ""}},
+	}
+	group := 0
+
+	scanner := bufio.NewScanner(r)
+	line_number := 0
+	for scanner.Scan() {
+		line_number++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Fields(text)
+		parseErr := func(msg string) error {
+			return &ParseError{line_number, text, msg}
+		}
+
+		switch fields[0] {
+		case "v":
+			v, ok := parseVec3(fields[1:], 3, 4)
+			if !ok {
+				return nil, parseErr("vertex needs 3 numbers")
+			}
+			f.Vertices = append(f.Vertices, v)
+		case "vn":
+			v, ok := parseVec3(fields[1:], 3, 3)
+			if !ok {
+				return nil, parseErr("vertex normal needs 3 numbers")
+			}
+			f.Normals = append(f.Normals, v)
+		case "vt":
+			v, ok := parseVec3(fields[1:], 1, 3)
+			if !ok {
+				return nil, parseErr("texture coordinate needs 1 to 3 numbers")
+			}
+			f.TexCoords = append(f.TexCoords, v)
+		case "f":
+			if len(fields) < 4 {
+				return nil, parseErr("face needs at least 3 vertices")
+			}
+			verts := []faceVertex{}
+			for _, field := range fields[1:] {
+				fv, err := f.parseFaceVertex(field)
+				if err != nil {
+					return nil, parseErr(err.Error())
+				}
+				verts = append(verts, fv)
+			}
+			f.Groups[group].Triangles = append(f.Groups[group].Triangles, f.fanTriangulate(verts)...)
+		case "g", "o":
+			name := strings.Join(fields[1:], " ")
+			group = f.findOrAddGroup(name)
+		default:
+			f.Ignored = append(f.Ignored, Line{line_number, text})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// Triangles returns the triangles of every group
+func (f *File) Triangles() []geom.Shape {
+	shapes := []geom.Shape{}
+	for _, g := range f.Groups {
+		shapes = append(shapes, g.Triangles...)
+	}
+	return shapes
+}
+
+func (f *File) Group(name string) (Group, bool) {
+	for _, g := range f.Groups {
+		if g.Name == name {
+			return g, true
+		}
+	}
+	return Group{}, false
+}
+
+func (f *File) findOrAddGroup(name string) int {
+	for i, g := range f.Groups {
+		if g.Name == name {
+			return i
+		}
+	}
+	f.Groups = append(f.Groups, Group{Name: name})
+	return len(f.Groups) - 1
+}
+
+func parseVec3(fields []string, min_count, max_count int) (nmath.Vec3, bool) {
+	if len(fields) < min_count || len(fields) > max_count {
+		return nmath.Vec3{}, false
+	}
+	values := [3]float64{}
+	for i, field := range fields {
+		n, err := strconv.ParseFloat(field, 64)
+		if err != nil {
+			return nmath.Vec3{}, false
+		}
+		// the 4th value of a vertex is a weight we don't use
+		if i < 3 {
+			values[i] = n
+		}
+	}
+	return nmath.NewVec3(values[0], values[1], values[2]), true
+}
+
+// parseFaceVertex parses v, v/vt, v//vn and v/vt/vn references
+func (f *File) parseFaceVertex(field string) (faceVertex, error) {
+	parts := strings.Split(field, "/")
+	if len(parts) > 3 {
+		return faceVertex{}, fmt.Errorf("bad face vertex %q", field)
+	}
+
+	fv := faceVertex{-1, -1, -1}
+	counts := []int{len(f.Vertices), len(f.TexCoords), len(f.Normals)}
+	names := []string{"vertex", "texture coordinate", "normal"}
+	out := []*int{&fv.v, &fv.vt, &fv.vn}
+	for i, part := range parts {
+		if part == "" {
+			if i == 0 {
+				return faceVertex{}, fmt.Errorf("face vertex %q has no vertex index", field)
+			}
+			continue
+		}
+		idx, err := resolveIndex(part, counts[i])
+		if err != nil {
+			return faceVertex{}, fmt.Errorf("%s index %s", names[i], err)
+		}
+		*out[i] = idx
+	}
+	return fv, nil
+}
+
+// resolveIndex turns a 1 based or negative relative index into a zero based one
+func resolveIndex(s string, count int) (int, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not an integer", s)
+	}
+	idx := n - 1
+	if n < 0 {
+		idx = count + n
+	}
+	if n == 0 || idx < 0 || idx >= count {
+		return 0, fmt.Errorf("%d is out of range", n)
+	}
+	return idx, nil
+}
+
+// fanTriangulate splits a convex polygon into triangles that all share the first vertex.
+// SmoothTriangles are used when every vertex of the face has a normal.
+func (f *File) fanTriangulate(verts []faceVertex) []geom.Shape {
+	smooth := true
+	for _, v := range verts {
+		if v.vn < 0 {
+			smooth = false
+		}
+	}
+
+	shapes := []geom.Shape{}
+	for i := 1; i < len(verts)-1; i++ {
+		a, b, c := verts[0], verts[i], verts[i+1]
+		p1, p2, p3 := f.Vertices[a.v], f.Vertices[b.v], f.Vertices[c.v]
+		if smooth {
+			t := geom.NewSmoothTriangle(p1, p2, p3, f.Normals[a.vn], f.Normals[b.vn], f.Normals[c.vn])
+			shapes = append(shapes, &t)
+		} else {
+			t := geom.NewTriangle(p1, p2, p3)
+			shapes = append(shapes, &t)
+		}
+	}
+	return shapes
+}
+
+// AsGroup returns every triangle in a geom.Group, each named group of the file
+// becomes a sub group. Call Divide on the result to speed up large meshes.
+func (f *File) AsGroup() *geom.Group {
+	g := geom.NewGroup()
+	for _, group := range f.Groups {
+		if group.Name == "" {
+			g.AddChild(group.Triangles...)
+			continue
+		}
+		if len(group.Triangles) == 0 {
+			continue
+		}
+		sub := geom.NewGroup()
+		sub.AddChild(group.Triangles...)
+		g.AddChild(&sub)
+	}
+	return &g
+}
@@ -0,0 +1,135 @@
+package obj_test
+
+import (
+	"errors"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/novelalex/soft-raytracer/pkg/geom"
+	nm "github.com/novelalex/soft-raytracer/pkg/nmath"
+	"github.com/novelalex/soft-raytracer/pkg/obj"
+)
+
+var _ = Describe("Parse", func() {
+	It("records lines it doesn't understand", func() {
+		src := `There was a young lady named Bright
+who traveled much faster than light.
+v 1 2 3
+mtllib scene.mtl
+`
+		f, err := obj.Parse(strings.NewReader(src))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(f.Ignored).To(HaveLen(3))
+		Expect(f.Ignored[0].Number).To(Equal(1))
+		Expect(f.Ignored[2].Number).To(Equal(4))
+	})
+
+	It("reads vertices, normals and texture coordinates", func() {
+		src := `v -1 1 0
+v -1.0000 0.5000 0.0000
+v 1 0 0 1.0
+vn 0 0 1
+vn 0.707 0 -0.707
+vt 0.5 0.25
+`
+		f, err := obj.Parse(strings.NewReader(src))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(f.Vertices).To(HaveLen(3))
+		Expect(f.Vertices[1].ApproxEq(nm.NewVec3(-1, 0.5, 0))).To(BeTrue())
+		Expect(f.Normals).To(HaveLen(2))
+		Expect(f.Normals[1].ApproxEq(nm.NewVec3(0.707, 0, -0.707))).To(BeTrue())
+		Expect(f.TexCoords[0].ApproxEq(nm.NewVec3(0.5, 0.25, 0))).To(BeTrue())
+	})
+
+	It("fan triangulates polygons", func() {
+		src := `v -1 1 0
+v -1 0 0
+v 1 0 0
+v 1 1 0
+v 0 2 0
+f 1 2 3 4 5
+`
+		f, err := obj.Parse(strings.NewReader(src))
+		Expect(err).NotTo(HaveOccurred())
+
+		tris := f.Groups[0].Triangles
+		Expect(tris).To(HaveLen(3))
+		t3 := tris[2].(*geom.Triangle)
+		Expect(t3.P1.ApproxEq(f.Vertices[0])).To(BeTrue())
+		Expect(t3.P2.ApproxEq(f.Vertices[3])).To(BeTrue())
+		Expect(t3.P3.ApproxEq(f.Vertices[4])).To(BeTrue())
+	})
+
+	It("puts faces into named groups", func() {
+		src := `v -1 1 0
+v -1 0 0
+v 1 0 0
+v 1 1 0
+g FirstGroup
+f 1 2 3
+g SecondGroup
+f 1 3 4
+`
+		f, err := obj.Parse(strings.NewReader(src))
+		Expect(err).NotTo(HaveOccurred())
+
+		first, ok := f.Group("FirstGroup")
+		Expect(ok).To(BeTrue())
+		Expect(first.Triangles).To(HaveLen(1))
+		second, ok := f.Group("SecondGroup")
+		Expect(ok).To(BeTrue())
+		Expect(second.Triangles).To(HaveLen(1))
+		Expect(f.Triangles()).To(HaveLen(2))
+
+		g := f.AsGroup()
+		Expect(g.Children).To(HaveLen(2))
+		Expect(g.Children[0].(*geom.Group).Children).To(HaveLen(1))
+	})
+
+	It("makes smooth triangles from faces with normals", func() {
+		src := `v 0 1 0
+v -1 0 0
+v 1 0 0
+vn -1 0 0
+vn 1 0 0
+vn 0 1 0
+f 1//3 2//1 3//2
+f 1/0/3 2/102/1 -1/1/2
+`
+		_, err := obj.Parse(strings.NewReader(src))
+		var perr *obj.ParseError
+		Expect(errors.As(err, &perr)).To(BeTrue())
+		Expect(perr.Line).To(Equal(8))
+
+		src = strings.Replace(src, "f 1/0/3 2/102/1 -1/1/2\n", "f 1//3 2//1 -1//-2\n", 1)
+		f, err := obj.Parse(strings.NewReader(src))
+		Expect(err).NotTo(HaveOccurred())
+
+		tris := f.Triangles()
+		Expect(tris).To(HaveLen(2))
+		t := tris[1].(*geom.SmoothTriangle)
+		Expect(t.P3.ApproxEq(f.Vertices[2])).To(BeTrue())
+		Expect(t.N1.ApproxEq(f.Normals[2])).To(BeTrue())
+		Expect(t.N3.ApproxEq(f.Normals[1])).To(BeTrue())
+	})
+
+	It("reports malformed lines with their line number", func() {
+		cases := []struct {
+			src  string
+			line int
+		}{
+			{"v 1 2\n", 1},
+			{"v 1 2 3\nv 1 2 x\n", 2},
+			{"v 1 2 3\n\nf 1 2\n", 3},
+			{"v 1 2 3\nv 1 2 3\nv 1 2 3\nf 1 2 4\n", 4},
+		}
+		for _, c := range cases {
+			_, err := obj.Parse(strings.NewReader(c.src))
+			var perr *obj.ParseError
+			Expect(errors.As(err, &perr)).To(BeTrue())
+			Expect(perr.Line).To(Equal(c.line))
+		}
+	})
+})
@@ -0,0 +1,226 @@
+package raytracer
+
+import (
+	"math"
+
+	"github.com/novelalex/soft-raytracer/pkg/geom"
+	"github.com/novelalex/soft-raytracer/pkg/nmath"
+)
+
+const (
+	bvhBinCount       = 16
+	bvhMaxLeafSize    = 4
+	bvhTraversalCost  = 1.0
+	bvhIntersectCost  = 1.0
+	bvhForceSplitSize = 16
+)
+
+// bvh is a bounding volume hierarchy over the objects of a World.
+// Nodes are stored flattened in depth first order, so the left child of an
+// interior node is always the node right after it.
+type bvh struct {
+	nodes     []bvhNode
+	indices   []int
+	unbounded []int
+}
+
+type bvhNode struct {
+	bounds geom.AABB
+	right  int
+	start  int
+	count  int // zero for interior nodes
+}
+
+type bvhPrimitive struct {
+	index    int
+	bounds   geom.AABB
+	centroid nmath.Vec3
+}
+
+type bvhBin struct {
+	bounds geom.AABB
+	count  int
+}
+
+// newBVH builds a hierarchy over objects using a binned surface area heuristic.
+// Objects with infinite bounds (planes) are kept out of the tree and always tested.
+func newBVH(objects []Object) *bvh {
+	b := &bvh{}
+	prims := []bvhPrimitive{}
+	for i := range objects {
+		// padded so rays grazing a shape within the intersection tolerance still reach it
+		bounds := objects[i].Shape.Bounds().Pad(nmath.F64EpsilonLoose)
+		if bounds.IsInfinite() {
+			b.unbounded = append(b.unbounded, i)
+			continue
+		}
+		prims = append(prims, bvhPrimitive{i, bounds, bounds.Center()})
+	}
+
+	if len(prims) > 0 {
+		b.build(prims)
+	}
+	return b
+}
+
+func (b *bvh) build(prims []bvhPrimitive) int {
+	node_index := len(b.nodes)
+	b.nodes = append(b.nodes, bvhNode{})
+
+	bounds := geom.EmptyAABB()
+	centroid_bounds := geom.EmptyAABB()
+	for _, p := range prims {
+		bounds = bounds.Union(p.bounds)
+		centroid_bounds = centroid_bounds.AddPoint(p.centroid)
+	}
+
+	if len(prims) <= bvhMaxLeafSize {
+		b.makeLeaf(node_index, bounds, prims)
+		return node_index
+	}
+
+	axis, split, cost, ok := bvhFindSplit(prims, bounds, centroid_bounds)
+	leaf_cost := bvhIntersectCost * float64(len(prims))
+	if !ok || (cost >= leaf_cost && len(prims) <= bvhForceSplitSize) {
+		b.makeLeaf(node_index, bounds, prims)
+		return node_index
+	}
+
+	lo, hi := axisRange(centroid_bounds, axis)
+	mid := 0
+	for i := range prims {
+		if bvhBinIndex(vecAxis(prims[i].centroid, axis), lo, hi) <= split {
+			prims[i], prims[mid] = prims[mid], prims[i]
+			mid++
+		}
+	}
+	if mid == 0 || mid == len(prims) {
+		b.makeLeaf(node_index, bounds, prims)
+		return node_index
+	}
+
+	b.build(prims[:mid])
+	right := b.build(prims[mid:])
+	b.nodes[node_index] = bvhNode{bounds: bounds, right: right}
+	return node_index
+}
+
+func (b *bvh) makeLeaf(node_index int, bounds geom.AABB, prims []bvhPrimitive) {
+	start := len(b.indices)
+	for _, p := range prims {
+		b.indices = append(b.indices, p.index)
+	}
+	b.nodes[node_index] = bvhNode{bounds: bounds, start: start, count: len(prims)}
+}
+
+// bvhFindSplit bins the primitive centroids along each axis and returns the
+// axis and last bin of the left partition with the lowest SAH cost
+func bvhFindSplit(prims []bvhPrimitive, bounds, centroid_bounds geom.AABB) (int, int, float64, bool) {
+	parent_area := bounds.SurfaceArea()
+	best_axis, best_split := -1, -1
+	best_cost := math.Inf(1)
+
+	for axis := range 3 {
+		lo, hi := axisRange(centroid_bounds, axis)
+		if hi-lo <= 0 {
+			continue
+		}
+
+		bins := [bvhBinCount]bvhBin{}
+		for i := range bins {
+			bins[i].bounds = geom.EmptyAABB()
+		}
+		for _, p := range prims {
+			i := bvhBinIndex(vecAxis(p.centroid, axis), lo, hi)
+			bins[i].count++
+			bins[i].bounds = bins[i].bounds.Union(p.bounds)
+		}
+
+		// sweep from the right so each split can be costed in one pass from the left
+		right_area := [bvhBinCount]float64{}
+		right_count := [bvhBinCount]int{}
+		acc := geom.EmptyAABB()
+		count := 0
+		for i := bvhBinCount - 1; i > 0; i-- {
+			acc = acc.Union(bins[i].bounds)
+			count += bins[i].count
+			right_area[i] = acc.SurfaceArea()
+			right_count[i] = count
+		}
+
+		acc = geom.EmptyAABB()
+		count = 0
+		for i := 0; i < bvhBinCount-1; i++ {
+			acc = acc.Union(bins[i].bounds)
+			count += bins[i].count
+			if count == 0 || right_count[i+1] == 0 {
+				continue
+			}
+			cost := bvhTraversalCost
+			if parent_area > 0 {
+				cost += bvhIntersectCost * (float64(count)*acc.SurfaceArea() +
+					float64(right_count[i+1])*right_area[i+1]) / parent_area
+			} else {
+				cost += bvhIntersectCost * float64(len(prims)) / 2
+			}
+			if cost < best_cost {
+				best_axis, best_split, best_cost = axis, i, cost
+			}
+		}
+	}
+
+	return best_axis, best_split, best_cost, best_axis >= 0
+}
+
+func bvhBinIndex(c, lo, hi float64) int {
+	i := int(bvhBinCount * (c - lo) / (hi - lo))
+	return min(max(i, 0), bvhBinCount-1)
+}
+
+func vecAxis(v nmath.Vec3, axis int) float64 {
+	switch axis {
+	case 0:
+		return v.X
+	case 1:
+		return v.Y
+	default:
+		return v.Z
+	}
+}
+
+func axisRange(b geom.AABB, axis int) (float64, float64) {
+	return vecAxis(b.Min, axis), vecAxis(b.Max, axis)
+}
+
+// traverse calls visit with the index of every object whose bounds the ray
+// overlaps in [tmin, tmax], along with every unbounded object
+func (b *bvh) traverse(r geom.Ray, tmin, tmax float64, visit func(int)) {
+	for _, i := range b.unbounded {
+		visit(i)
+	}
+	if len(b.nodes) == 0 {
+		return
+	}
+
+	stack := make([]int, 0, 64)
+	stack = append(stack, 0)
+	for len(stack) > 0 {
+		n := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		node := &b.nodes[n]
+		enter, exit, ok := node.bounds.IntersectRay(r)
+		if !ok || max(enter, tmin) > min(exit, tmax) {
+			continue
+		}
+
+		if node.count > 0 {
+			for _, i := range b.indices[node.start : node.start+node.count] {
+				visit(i)
+			}
+			continue
+		}
+
+		stack = append(stack, node.right, n+1)
+	}
+}
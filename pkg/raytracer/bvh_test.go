@@ -0,0 +1,78 @@
+package raytracer_test
+
+import (
+	"math/rand/v2"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/novelalex/soft-raytracer/pkg/geom"
+	"github.com/novelalex/soft-raytracer/pkg/nmath"
+	"github.com/novelalex/soft-raytracer/pkg/raytracer"
+)
+
+var _ = Describe("BVH", func() {
+	randomWorld := func(rng *rand.Rand, n int) raytracer.World {
+		objects := []raytracer.Object{}
+		floor := geom.DefaultPlane()
+		floor.Translate(0, -10, 0)
+		objects = append(objects, raytracer.NewObject(&floor, raytracer.DefaultMaterial()))
+
+		for i := range n {
+			x := rng.Float64()*20 - 10
+			y := rng.Float64()*20 - 10
+			z := rng.Float64()*20 - 10
+			s := rng.Float64()*0.5 + 0.1
+			if i%2 == 0 {
+				shape := geom.DefaultSphere()
+				shape.Translate(x, y, z).Scale(s, s, s)
+				objects = append(objects, raytracer.NewObject(&shape, raytracer.DefaultMaterial()))
+			} else {
+				shape := geom.DefaultCube()
+				shape.Translate(x, y, z).RotateY(rng.Float64()).Scale(s, s*2, s)
+				objects = append(objects, raytracer.NewObject(&shape, raytracer.DefaultMaterial()))
+			}
+		}
+		return raytracer.NewWorldWith([]raytracer.Light{}, objects)
+	}
+
+	Describe("IntersectRay", func() {
+		It("should return the same intersections as the linear scan", func() {
+			rng := rand.New(rand.NewPCG(1, 2))
+			linear := randomWorld(rng, 500)
+			accelerated := linear
+			accelerated.BuildBVH()
+
+			for range 1000 {
+				origin := nmath.NewVec3(rng.Float64()*40-20, rng.Float64()*40-20, rng.Float64()*40-20)
+				dir := nmath.NewVec3(rng.Float64()*2-1, rng.Float64()*2-1, rng.Float64()*2-1).Normalize()
+				r := geom.NewRay(origin, dir)
+
+				expected := linear.IntersectRay(r)
+				result := accelerated.IntersectRay(r)
+
+				Expect(len(result)).To(Equal(len(expected)))
+				for i := range expected {
+					Expect(result[i].ApproxEq(expected[i])).To(BeTrue())
+				}
+			}
+		})
+	})
+
+	Describe("IsShadowed", func() {
+		It("should agree with the linear scan", func() {
+			rng := rand.New(rand.NewPCG(3, 4))
+			linear := randomWorld(rng, 200)
+			accelerated := linear
+			accelerated.BuildBVH()
+			light := raytracer.NewPointLight(nmath.NewVec3(0, 15, 0), nmath.NewColor(1, 1, 1))
+
+			for range 500 {
+				p := nmath.NewVec3(rng.Float64()*20-10, rng.Float64()*20-10, rng.Float64()*20-10)
+				expected, _ := linear.IsShadowed(p, light)
+				result, _ := accelerated.IsShadowed(p, light)
+				Expect(result).To(Equal(expected))
+			}
+		})
+	})
+})
@@ -1,12 +1,12 @@
 package raytracer
 
 import (
-	"runtime"
-	"sync"
+	"math/rand/v2"
 
 	"github.com/novelalex/soft-raytracer/pkg/geom"
 	"github.com/novelalex/soft-raytracer/pkg/gfx"
 	"github.com/novelalex/soft-raytracer/pkg/nmath"
+	"github.com/novelalex/soft-raytracer/pkg/sampling"
 )
 
 type Camera struct {
@@ -17,97 +17,180 @@ type Camera struct {
 	HalfWidth  float64
 	HalfHeight float64
 	PixelSize  float64
+
+	// Projection maps the image to rays, a Perspective with FOV if nil
+	Projection Projection
+
+	// Workers is the number of goroutines Render uses, runtime.NumCPU() if zero
+	Workers int
+	// TileSize is the width and height of the square tiles the image is
+	// split into for rendering, DefaultTileSize if zero
+	TileSize uint
+
+	// Samples is the number of rays traced per pixel, one if zero
+	Samples int
+	// Sampler places the samples inside a pixel, sampling.Regular if nil
+	Sampler sampling.Sampler
+	// Filter reconstructs pixels from the samples, gfx.NewBoxFilter() if nil
+	Filter gfx.Filter
+	// Seed makes randomized samplers reproducible, the same seed renders the same image
+	Seed uint64
+
+	// Aperture is the radius of the lens, zero is a pinhole camera with everything in focus
+	Aperture float64
+	// FocalDistance is the distance in front of the camera that is in focus, one if zero
+	FocalDistance float64
+	// Blades is the number of aperture blades that shape the bokeh, a round lens if less than three
+	Blades int
+
+	// Integrator computes the color of each sample, Whitted if nil
+	Integrator Integrator
+
+	// xf caches Transform and its inverse for the copy of the camera a render
+	// uses, nil otherwise since Transform can be changed at any time
+	xf *nmath.Transform
 }
 
 func NewCamera(w, h uint, fov float64) Camera {
 	c := Camera{
-		w, h, fov, nmath.Mat4Identity(), 0, 0, 0,
+		Width:     w,
+		Height:    h,
+		FOV:       fov,
+		Transform: nmath.Mat4Identity(),
 	}
 	c.ComputePixelSize()
 	return c
 }
 
 func (c *Camera) ComputePixelSize() {
-	half_view := c.FOV / 2.0
-	aspect := float64(c.Width) / float64(c.Height)
-	if aspect >= 1 {
-		c.HalfWidth = half_view
-		c.HalfHeight = half_view / aspect
-	} else {
-		c.HalfWidth = half_view * aspect
-		c.HalfHeight = half_view
+	c.HalfWidth, c.HalfHeight, c.PixelSize = perspectiveView(c.FOV, c.Width, c.Height)
+}
+
+func (c Camera) projection() Projection {
+	if c.Projection == nil {
+		return Perspective{c.FOV}
 	}
-	c.PixelSize = (c.HalfWidth * 2.0) / float64(c.Width)
+	return c.Projection
 }
 
+// RayForPixel returns the ray through the center of the pixel,
+// it is only meaningful if the projection covers the pixel
 func (c Camera) RayForPixel(px, py uint) geom.Ray {
-	x_offset := (float64(px) + 0.5) * c.PixelSize
-	y_offset := (float64(py) + 0.5) * c.PixelSize
-
-	world_x := c.HalfWidth - x_offset
-	world_y := c.HalfHeight - y_offset
-
-	pixel := c.Transform.Inverse().MultV(nmath.NewPoint4(world_x, world_y, -1)).DropW()
-	origin := c.Transform.Inverse().MultV(nmath.NewPoint4(0, 0, 0)).DropW()
-	direction := pixel.Sub(origin).Normalize()
+	ray, _ := c.RayForSample(float64(px)+0.5, float64(py)+0.5)
+	return ray
+}
 
-	return geom.NewRay(origin, direction)
+// RayForSample returns the ray through the continuous image position (x, y),
+// the center of pixel (px, py) is at (px+0.5, py+0.5). It returns false
+// if the projection doesn't cover (x, y).
+func (c Camera) RayForSample(x, y float64) (geom.Ray, bool) {
+	ray, ok := c.projection().CameraRay(x, y, c.Width, c.Height)
+	if !ok {
+		return geom.Ray{}, false
+	}
+	return c.toWorld(ray.Origin, ray.Dir), true
 }
 
-func (c *Camera) Render(w World) gfx.Canvas {
-	image := gfx.NewCanvas(c.Width, c.Height)
+// RayThroughLens is RayForSample for a ray leaving the lens at the point lens in
+// the unit square, which is mapped onto the aperture. Every ray through (x, y)
+// meets at FocalDistance, so only things at that distance are sharp.
+func (c Camera) RayThroughLens(x, y float64, lens sampling.Point) (geom.Ray, bool) {
+	if c.Aperture <= 0 {
+		return c.RayForSample(x, y)
+	}
 
-	jobs := make(chan renderWorkerJob, c.Width*c.Height)
-	results := make(chan renderWorkerResult, c.Width*c.Height)
+	ray, ok := c.projection().CameraRay(x, y, c.Width, c.Height)
+	if !ok {
+		return geom.Ray{}, false
+	}
 
-	num_workers := runtime.NumCPU()
-	var wg sync.WaitGroup
-	for i := 0; i < num_workers; i++ {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			renderWorker(jobs, results)
-		}()
+	focal_distance := c.FocalDistance
+	if focal_distance <= 0 {
+		focal_distance = 1
 	}
 
-	for y := range c.Height {
-		for x := range c.Width {
-			jobs <- renderWorkerJob{c, w, x, y}
-		}
+	var l sampling.Point
+	if c.Blades >= 3 {
+		l = sampling.Polygon(lens, c.Blades)
+	} else {
+		l = sampling.ConcentricDisk(lens)
 	}
-	close(jobs)
 
-	go func() {
-		wg.Wait()
-		close(results)
-	}()
+	// the plane of focus faces the camera, rays that don't point forward
+	// focus at FocalDistance along the ray instead
+	var focus nmath.Vec3
+	if ray.Dir.Z < 0 {
+		focus = ray.At(focal_distance / -ray.Dir.Z)
+	} else {
+		focus = ray.At(focal_distance / ray.Dir.Mag())
+	}
+	origin := ray.Origin.Add(nmath.NewVec3(l.X*c.Aperture, l.Y*c.Aperture, 0))
 
-	for result := range results {
-		image.WritePixel(result.X, result.Y, result.C)
+	return c.toWorld(origin, focus.Sub(origin)), true
+}
+
+// toWorld transforms a ray from camera space to world space
+func (c Camera) toWorld(origin, direction nmath.Vec3) geom.Ray {
+	var inverse nmath.Mat4
+	if c.xf != nil {
+		inverse = c.xf.Inverse()
+	} else {
+		inverse = c.Transform.Inverse()
 	}
+	world_origin := inverse.MultV(origin.AsPoint4()).DropW()
+	world_direction := inverse.MultV(direction.AsVector4()).DropW().Normalize()
+	return geom.NewRay(world_origin, world_direction)
+}
 
-	return image
+// withCachedInverse is a copy of the camera that inverts Transform once
+// instead of for every ray
+func (c Camera) withCachedInverse() Camera {
+	xf := nmath.NewTransform(c.Transform)
+	c.xf = &xf
+	return c
 }
 
-type renderWorkerJob struct {
-	C *Camera
-	W World
-	X uint
-	Y uint
+func (c Camera) sampleCount() int {
+	return max(c.Samples, 1)
 }
 
-type renderWorkerResult struct {
-	C nmath.Color
-	X uint
-	Y uint
+func (c Camera) sampler() sampling.Sampler {
+	if c.Sampler == nil {
+		return sampling.Regular{}
+	}
+	return c.Sampler
 }
 
-func renderWorker(jobs <-chan renderWorkerJob, results chan<- renderWorkerResult) {
-	for job := range jobs {
-		ray := job.C.RayForPixel(job.X, job.Y)
-		color := job.W.ColorAt(ray, 5)
-		results <- renderWorkerResult{
-			color, job.X, job.Y,
-		}
+func (c Camera) integrator() Integrator {
+	if c.Integrator == nil {
+		return Whitted{}
 	}
+	return c.Integrator
+}
+
+func (c Camera) filter() gfx.Filter {
+	if c.Filter == nil {
+		return gfx.NewBoxFilter()
+	}
+	return c.Filter
+}
+
+// lensSamples places a lens sample for each of the n samples of a pixel, shuffled
+// so they aren't correlated with the positions in the pixel. They are nil for a
+// pinhole camera so it doesn't use up random numbers.
+func (c Camera) lensSamples(sampler sampling.Sampler, n int, rng *rand.Rand) []sampling.Point {
+	if c.Aperture <= 0 {
+		return nil
+	}
+	points := sampler.Samples(n, rng)
+	rng.Shuffle(len(points), func(i, j int) {
+		points[i], points[j] = points[j], points[i]
+	})
+	return points
+}
+
+// pixelRand is seeded by the pixel so the samples don't depend on
+// which worker renders it or in what order
+func (c Camera) pixelRand(px, py uint) *rand.Rand {
+	return rand.New(rand.NewPCG(c.Seed, uint64(py)*uint64(c.Width)+uint64(px)))
 }
@@ -6,8 +6,10 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
+	"github.com/novelalex/soft-raytracer/pkg/geom"
 	"github.com/novelalex/soft-raytracer/pkg/nmath"
 	"github.com/novelalex/soft-raytracer/pkg/raytracer"
+	"github.com/novelalex/soft-raytracer/pkg/sampling"
 )
 
 var _ = Describe("Camera", func() {
@@ -25,4 +27,47 @@ var _ = Describe("Camera", func() {
 			})
 		})
 	})
+
+	Describe("RayThroughLens", func() {
+		atDepth := func(r geom.Ray, z float64) nmath.Vec3 {
+			return r.At((z - r.Origin.Z) / r.Dir.Z)
+		}
+
+		It("should match RayForSample for a pinhole camera", func() {
+			c := raytracer.NewCamera(11, 11, math.Pi/2.0)
+			r, _ := c.RayThroughLens(3.2, 7.9, sampling.Point{X: 0.1, Y: 0.9})
+			expected, _ := c.RayForSample(3.2, 7.9)
+			Expect(r.ApproxEq(expected)).To(BeTrue())
+		})
+
+		It("should focus the rays through a pixel at the focal distance", func() {
+			c := raytracer.NewCamera(11, 11, math.Pi/2.0)
+			c.Aperture = 0.5
+			c.FocalDistance = 4
+
+			pinhole, _ := c.RayForSample(2.5, 8.5)
+			center := atDepth(pinhole, -4)
+			for _, lens := range []sampling.Point{{X: 0.1, Y: 0.2}, {X: 0.9, Y: 0.5}, {X: 0.4, Y: 0.95}} {
+				r, _ := c.RayThroughLens(2.5, 8.5, lens)
+				Expect(r.Origin.ApproxEq(nmath.NewVec3(0, 0, 0))).To(BeFalse())
+				Expect(math.Hypot(r.Origin.X, r.Origin.Y)).To(BeNumerically("<=", 0.5))
+				Expect(atDepth(r, -4).ApproxEq(center)).To(BeTrue())
+			}
+		})
+
+		It("should keep the rays inside the aperture blades", func() {
+			c := raytracer.NewCamera(11, 11, math.Pi/2.0)
+			c.Aperture = 1
+			c.Blades = 6
+			apothem := math.Cos(math.Pi / 6)
+			for _, lens := range (sampling.Regular{}).Samples(64, nil) {
+				r, _ := c.RayThroughLens(5.5, 5.5, lens)
+				o := r.Origin
+				Expect(math.Hypot(o.X, o.Y)).To(BeNumerically("<=", 1+nmath.F64Epsilon))
+				// the direction of a flat edge, halfway between two corners
+				edge := math.Pi / 6
+				Expect(o.X*math.Cos(edge) + o.Y*math.Sin(edge)).To(BeNumerically("<=", apothem+nmath.F64Epsilon))
+			}
+		})
+	})
 })
@@ -0,0 +1,185 @@
+package raytracer
+
+import (
+	"math/rand/v2"
+
+	"github.com/novelalex/soft-raytracer/pkg/geom"
+	"github.com/novelalex/soft-raytracer/pkg/nmath"
+	"github.com/novelalex/soft-raytracer/pkg/sampling"
+)
+
+// Integrator computes the light arriving at the camera along a ray.
+// Randomized integrators draw from rng, which is seeded per pixel.
+type Integrator interface {
+	Li(w *World, r geom.Ray, rng *rand.Rand) nmath.Color
+}
+
+const DefaultMaxDepth = 5
+
+// Whitted is the classic recursive ray tracer, direct lighting plus perfect
+// reflection and refraction up to MaxDepth bounces, DefaultMaxDepth if zero
+type Whitted struct {
+	MaxDepth int
+}
+
+func (i Whitted) Li(w *World, r geom.Ray, rng *rand.Rand) nmath.Color {
+	depth := i.MaxDepth
+	if depth <= 0 {
+		depth = DefaultMaxDepth
+	}
+	return w.ColorAt(r, depth)
+}
+
+// PathTracer follows a single random path for each camera ray, which brings
+// in indirect light bouncing between diffuse surfaces. At each hit the lights
+// are sampled directly (next-event estimation) and the path continues in a
+// direction picked by the material. Ambient is ignored, the indirect light
+// takes its place.
+type PathTracer struct {
+	// MaxDepth is the longest path, 8 if zero
+	MaxDepth int
+	// RouletteDepth is the number of bounces after which paths carrying
+	// little light are randomly terminated, 3 if zero
+	RouletteDepth int
+}
+
+// pathLobe is the way a path continues from a hit
+type pathLobe int
+
+const (
+	diffuseLobe pathLobe = iota
+	reflectLobe
+	refractLobe
+)
+
+func (pt PathTracer) Li(w *World, r geom.Ray, rng *rand.Rand) nmath.Color {
+	max_depth := pt.MaxDepth
+	if max_depth <= 0 {
+		max_depth = 8
+	}
+	roulette_depth := pt.RouletteDepth
+	if roulette_depth <= 0 {
+		roulette_depth = 3
+	}
+
+	radiance := nmath.NewColor(0, 0, 0)
+	throughput := nmath.NewColor(1, 1, 1)
+	ray := r
+
+	for depth := 0; ; depth++ {
+		xs := w.IntersectRay(ray)
+		hit, ok := xs.Hit()
+		if !ok {
+			break
+		}
+		comps := hit.Precompute(ray, xs)
+		material := comps.Object.Material
+
+		radiance = radiance.Add(throughput.HadamardMult(material.Emission))
+		radiance = radiance.Add(throughput.HadamardMult(w.directLight(comps)))
+
+		if depth+1 >= max_depth {
+			break
+		}
+
+		lobe, probability, ok := pickLobe(material, rng)
+		if !ok {
+			break
+		}
+
+		var direction nmath.Vec3
+		origin := comps.OverPoint
+		switch lobe {
+		case diffuseLobe:
+			if material.PBR != nil {
+				base := material.colorAt(comps.Shape, comps.Point)
+				var weight nmath.Color
+				direction, weight, ok = material.PBR.sample(base, comps.NormalV, comps.EyeV, rng)
+				if !ok {
+					return radiance
+				}
+				throughput = throughput.HadamardMult(weight)
+				break
+			}
+			direction = cosineDirection(comps.NormalV, rng)
+			albedo := material.colorAt(comps.Shape, comps.Point).AsVec3().Mult(material.Diffuse).AsColor()
+			throughput = throughput.HadamardMult(albedo)
+		case reflectLobe:
+			direction = comps.ReflectV
+			throughput = scaleColor(throughput, material.Reflective)
+		case refractLobe:
+			// a dielectric reflects part of the light, pick one by the Fresnel term
+			refracted, ok := refractDirection(comps)
+			if !ok || rng.Float64() < Schlick(comps) {
+				direction = comps.ReflectV
+			} else {
+				direction = refracted
+				origin = comps.UnderPoint
+			}
+			throughput = scaleColor(throughput, material.Transparency)
+		}
+		throughput = scaleColor(throughput, 1/probability)
+
+		if depth+1 >= roulette_depth {
+			survive := min(0.95, max(throughput.R, throughput.G, throughput.B))
+			if rng.Float64() >= survive {
+				break
+			}
+			throughput = scaleColor(throughput, 1/survive)
+		}
+
+		ray = geom.NewRay(origin, direction.Normalize())
+	}
+
+	return radiance
+}
+
+func scaleColor(c nmath.Color, s float64) nmath.Color {
+	return c.AsVec3().Mult(s).AsColor()
+}
+
+// directLight is the diffuse and specular light from every light,
+// without the ambient term
+func (w *World) directLight(comps IntersectionPrecomputation) nmath.Color {
+	material := comps.Object.Material
+
+	out_color := nmath.NewColor(0, 0, 0)
+	for _, light := range w.Lights {
+		samples := light.Samples(comps.OverPoint)
+		visibility := w.visibility(comps.OverPoint, samples)
+		out_color = out_color.Add(material.directLighting(comps.Shape, samples, comps.Point, comps.EyeV, comps.NormalV, visibility))
+	}
+	return out_color
+}
+
+// pickLobe chooses how the path continues with a probability proportional to
+// how much light each lobe of the material carries, false if it absorbs everything.
+// The diffuse lobe of a PBR material is its whole BRDF, which carries what
+// isn't reflected or transmitted.
+func pickLobe(m Material, rng *rand.Rand) (pathLobe, float64, bool) {
+	diffuse := m.Diffuse
+	if m.PBR != nil {
+		diffuse = max(0, 1-m.Reflective-m.Transparency)
+	}
+	total := diffuse + m.Reflective + m.Transparency
+	if total <= 0 {
+		return diffuseLobe, 0, false
+	}
+
+	u := rng.Float64() * total
+	if u < m.Transparency {
+		return refractLobe, m.Transparency / total, true
+	}
+	if u < m.Transparency+m.Reflective {
+		return reflectLobe, m.Reflective / total, true
+	}
+	return diffuseLobe, diffuse / total, true
+}
+
+// cosineDirection is a cosine weighted random direction around normal,
+// which cancels the cosine and pdf of a lambertian surface
+func cosineDirection(normal nmath.Vec3, rng *rand.Rand) nmath.Vec3 {
+	local := sampling.CosineHemisphere(sampling.Point{X: rng.Float64(), Y: rng.Float64()})
+	u, v := orthonormalBasis(normal)
+	return u.Mult(local.X).Add(v.Mult(local.Y)).Add(normal.Mult(local.Z))
+}
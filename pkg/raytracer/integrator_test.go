@@ -0,0 +1,99 @@
+package raytracer_test
+
+import (
+	"math"
+	"math/rand/v2"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/novelalex/soft-raytracer/pkg/geom"
+	"github.com/novelalex/soft-raytracer/pkg/nmath"
+	"github.com/novelalex/soft-raytracer/pkg/raytracer"
+)
+
+var _ = Describe("Integrator", func() {
+	Describe("Whitted", func() {
+		It("should match ColorAt", func() {
+			w := raytracer.NewWorld()
+			r := geom.NewRay(nmath.NewVec3(0, 0, -5), nmath.NewVec3(0, 0, 1))
+			Expect(raytracer.Whitted{}.Li(&w, r, nil)).To(Equal(w.ColorAt(r, raytracer.DefaultMaxDepth)))
+		})
+
+		It("should add the emission of a surface", func() {
+			w := raytracer.NewWorld()
+			w.Objects[0].Material.Emission = nmath.NewColor(0.5, 0, 0)
+			r := geom.NewRay(nmath.NewVec3(0, 0, -5), nmath.NewVec3(0, 0, 1))
+			Expect(w.ColorAt(r, 5).R).To(BeNumerically("~", 0.38066119308103435+0.5, 1e-6))
+		})
+	})
+
+	Describe("PathTracer", func() {
+		It("should see the emission of a surface that absorbs everything", func() {
+			s := geom.DefaultSphere()
+			o := raytracer.NewObject(&s, raytracer.DefaultMaterial())
+			o.Material.Diffuse = 0
+			o.Material.Emission = nmath.NewColor(2, 3, 4)
+			w := raytracer.NewWorldWith([]raytracer.Light{}, []raytracer.Object{o})
+
+			r := geom.NewRay(nmath.NewVec3(0, 0, -5), nmath.NewVec3(0, 0, 1))
+			c := raytracer.PathTracer{}.Li(&w, r, rand.New(rand.NewPCG(1, 2)))
+			Expect(c.AsVec3().ApproxEq(nmath.NewVec3(2, 3, 4))).To(BeTrue())
+		})
+
+		It("should be black when the ray hits nothing", func() {
+			w := raytracer.NewWorld()
+			r := geom.NewRay(nmath.NewVec3(0, 0, -5), nmath.NewVec3(0, 1, 0))
+			c := raytracer.PathTracer{}.Li(&w, r, rand.New(rand.NewPCG(1, 2)))
+			Expect(c.AsVec3().ApproxEq(nmath.NewVec3(0, 0, 0))).To(BeTrue())
+		})
+
+		It("should light a shadowed point by light bouncing off a wall", func() {
+			floor_shape := geom.DefaultPlane()
+			floor := raytracer.NewObject(&floor_shape, raytracer.DefaultMaterial())
+			floor.Material.Ambient = 0
+
+			wall_shape := geom.DefaultPlane()
+			wall_shape.SetTransform(nmath.NewTranslation(-2, 0, 0).Mult(nmath.NewRotationZ(math.Pi / 2)))
+			wall := raytracer.NewObject(&wall_shape, raytracer.DefaultMaterial())
+			wall.Material.Ambient = 0
+
+			blocker_shape := geom.DefaultSphere()
+			blocker_shape.Translate(0, 1, 0).Scale(0.5, 0.5, 0.5)
+			blocker := raytracer.NewObject(&blocker_shape, raytracer.DefaultMaterial())
+			blocker.Material.Ambient = 0
+
+			light := raytracer.NewPointLight(nmath.NewVec3(0, 3, 0), nmath.NewColor(1, 1, 1))
+			w := raytracer.NewWorldWith([]raytracer.Light{light}, []raytracer.Object{floor, wall, blocker})
+
+			r := geom.NewRay(nmath.NewVec3(1, 1, 0), nmath.NewVec3(-1, -1, 0).Normalize())
+			Expect(w.ColorAt(r, 5).AsVec3().ApproxEq(nmath.NewVec3(0, 0, 0))).To(BeTrue())
+
+			rng := rand.New(rand.NewPCG(7, 7))
+			sum := 0.0
+			for range 256 {
+				sum += raytracer.PathTracer{}.Li(&w, r, rng).R
+			}
+			Expect(sum / 256).To(BeNumerically(">", 0.01))
+		})
+
+		It("should render the same image for the same seed", func() {
+			w := raytracer.NewWorld()
+			c := raytracer.NewCamera(8, 8, math.Pi/2.0)
+			c.Transform = nmath.NewVec3(0, 0, -5).LookAt(nmath.NewVec3(0, 0, 0), nmath.NewVec3(0, 1, 0))
+			c.Integrator = raytracer.PathTracer{}
+			c.Samples = 4
+			c.Seed = 3
+			c.Workers = 1
+			expected := c.Render(w)
+
+			c.Workers = 3
+			result := c.Render(w)
+			for y := range uint(8) {
+				for x := range uint(8) {
+					Expect(result.PixelAt(x, y)).To(Equal(expected.PixelAt(x, y)))
+				}
+			}
+		})
+	})
+})
@@ -10,6 +10,12 @@ import (
 type Intersection struct {
 	T      float64
 	Object *Object
+	U      float64
+	V      float64
+	// Child and Inner are the geom.Hit fields shapes like geom.Group
+	// use to find the child that was hit when computing the normal
+	Child geom.Shape
+	Inner *geom.Hit
 }
 
 type IntersectionPrecomputation struct {
@@ -28,7 +34,22 @@ type IntersectionPrecomputation struct {
 }
 
 func NewIntersection(t float64, object *Object) Intersection {
-	return Intersection{t, object}
+	return Intersection{t, object, 0, 0, nil, nil}
+}
+
+// NewIntersectionUV creates an intersection that remembers where on
+// the shape's surface it was, see geom.Hit
+func NewIntersectionUV(t, u, v float64, object *Object) Intersection {
+	return Intersection{t, object, u, v, nil, nil}
+}
+
+func newIntersectionFromHit(hit geom.Hit, object *Object) Intersection {
+	return Intersection{hit.T, object, hit.U, hit.V, hit.Shape, hit.Inner}
+}
+
+// Hit converts the intersection back into the geom.Hit its shape returned
+func (x Intersection) Hit() geom.Hit {
+	return geom.Hit{T: x.T, U: x.U, V: x.V, Shape: x.Child, Inner: x.Inner}
 }
 
 type Intersections []Intersection
@@ -107,7 +128,12 @@ func objectSliceFindObjectIndex(objs []*Object, obj *Object) int {
 
 func (x Intersection) Precompute(r geom.Ray, xs Intersections) IntersectionPrecomputation {
 	point := r.At(x.T)
-	normal := x.Object.Shape.NormalAt(point)
+	geometric_normal := x.Object.Shape.NormalAt(point, x.Hit())
+	normal := geometric_normal
+	if bump := x.Object.Material.Bump; bump != nil {
+		frame := geom.NewTangentFrame(normal, x.Object.Shape.TangentAt(point, x.Hit()))
+		normal = bump.NormalAt(x.Object.Shape, point, frame)
+	}
 	eye := r.Dir.Neg()
 	reflect := r.Dir.Reflect(normal)
 
@@ -116,7 +142,7 @@ func (x Intersection) Precompute(r geom.Ray, xs Intersections) IntersectionPreco
 
 	containers := []*Object{}
 	for _, intersect := range xs {
-		if intersect.IsHit(xs) {
+		if intersect.ApproxEq(x) {
 			if len(containers) == 0 {
 				n1 = 1.0
 			} else {
@@ -130,7 +156,7 @@ func (x Intersection) Precompute(r geom.Ray, xs Intersections) IntersectionPreco
 		} else {
 			containers = append(containers, intersect.Object)
 		}
-		if intersect.IsHit(xs) {
+		if intersect.ApproxEq(x) {
 			if len(containers) == 0 {
 				n2 = 1.0
 			} else {
@@ -140,15 +166,18 @@ func (x Intersection) Precompute(r geom.Ray, xs Intersections) IntersectionPreco
 		}
 	}
 
+	// which side the eye is on comes from the geometry, a bumped normal can
+	// lean away from the eye without the ray having entered the surface
 	var inside bool
-	if normal.Dot(eye) < 0 {
+	if geometric_normal.Dot(eye) < 0 {
 		inside = true
+		geometric_normal = geometric_normal.Neg()
 		normal = normal.Neg()
 	} else {
 		inside = false
 	}
-	over_point := point.Add(normal.Mult(nmath.F64Epsilon))
-	under_point := point.Sub(normal.Mult(nmath.F64Epsilon))
+	over_point := point.Add(geometric_normal.Mult(nmath.F64Epsilon))
+	under_point := point.Sub(geometric_normal.Mult(nmath.F64Epsilon))
 	return IntersectionPrecomputation{
 		T:          x.T,
 		Object:     x.Object,
@@ -0,0 +1,110 @@
+package raytracer_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/novelalex/soft-raytracer/pkg/geom"
+	"github.com/novelalex/soft-raytracer/pkg/nmath"
+	"github.com/novelalex/soft-raytracer/pkg/raytracer"
+)
+
+var _ = Describe("Intersection", func() {
+	Describe("Precompute", func() {
+		It("should use u and v to interpolate a smooth triangle's normal", func() {
+			tri := geom.NewSmoothTriangle(
+				nmath.NewVec3(0, 1, 0), nmath.NewVec3(-1, 0, 0), nmath.NewVec3(1, 0, 0),
+				nmath.NewVec3(0, 1, 0), nmath.NewVec3(-1, 0, 0), nmath.NewVec3(1, 0, 0),
+			)
+			o := raytracer.NewObject(&tri, raytracer.DefaultMaterial())
+			r := geom.NewRay(nmath.NewVec3(-0.2, 0.3, -2), nmath.NewVec3(0, 0, 1))
+
+			xs := o.IntersectRay(r)
+			Expect(xs).To(HaveLen(1))
+			Expect(nmath.ApproxEq(xs[0].U, 0.45)).To(BeTrue())
+			Expect(nmath.ApproxEq(xs[0].V, 0.25)).To(BeTrue())
+
+			comps := xs[0].Precompute(r, xs)
+			Expect(nmath.LooseEq(comps.NormalV.X, -0.5547)).To(BeTrue())
+			Expect(nmath.LooseEq(comps.NormalV.Y, 0.83205)).To(BeTrue())
+		})
+
+		It("should ask the child of a group for the normal", func() {
+			s := geom.DefaultSphere()
+			s.Translate(5, 0, 0)
+			g := geom.NewGroup()
+			g.Scale(2, 2, 2)
+			g.AddChild(&s)
+			o := raytracer.NewObject(&g, raytracer.DefaultMaterial())
+			r := geom.NewRay(nmath.NewVec3(10, 0, -10), nmath.NewVec3(0, 0, 1))
+
+			xs := o.IntersectRay(r)
+			Expect(xs).To(HaveLen(2))
+
+			comps := xs[0].Precompute(r, xs)
+			Expect(comps.NormalV.ApproxEq(nmath.NewVec3(0, 0, -1))).To(BeTrue())
+		})
+
+		It("should use the material's bump for the shading normal", func() {
+			p := geom.NewPlane(nmath.Mat4Identity())
+			m := raytracer.DefaultMaterial()
+			bump := geom.NewHeightBump(func(p nmath.Vec3) float64 { return p.X }, 1)
+			m.Bump = &bump
+			o := raytracer.NewObject(&p, m)
+			r := geom.NewRay(nmath.NewVec3(0, 1, -1), nmath.NewVec3(0, -1, 1).Normalize())
+
+			xs := o.IntersectRay(r)
+			Expect(xs).To(HaveLen(1))
+
+			comps := xs[0].Precompute(r, xs)
+			Expect(comps.NormalV.ApproxEq(nmath.NewVec3(-1, 1, 0).Normalize())).To(BeTrue())
+			Expect(comps.ReflectV.ApproxEq(r.Dir.Reflect(comps.NormalV))).To(BeTrue())
+			Expect(comps.Inside).To(BeFalse())
+			// the surface doesn't move, so points are still offset along the geometric normal
+			Expect(comps.OverPoint.X).To(Equal(comps.Point.X))
+			Expect(comps.OverPoint.Y).To(BeNumerically(">", comps.Point.Y))
+		})
+
+		It("should flip the bumped normal when the hit is inside", func() {
+			p := geom.NewPlane(nmath.Mat4Identity())
+			m := raytracer.DefaultMaterial()
+			bump := geom.NewHeightBump(func(p nmath.Vec3) float64 { return p.X }, 1)
+			m.Bump = &bump
+			o := raytracer.NewObject(&p, m)
+			r := geom.NewRay(nmath.NewVec3(0, -1, 0), nmath.NewVec3(0, 1, 0))
+
+			xs := o.IntersectRay(r)
+			comps := xs[0].Precompute(r, xs)
+			Expect(comps.Inside).To(BeTrue())
+			Expect(comps.NormalV.ApproxEq(nmath.NewVec3(1, -1, 0).Normalize())).To(BeTrue())
+			Expect(comps.OverPoint.Y).To(BeNumerically("<", comps.Point.Y))
+		})
+
+		It("should find n1 and n2 across a lens made from two glass spheres", func() {
+			s1 := geom.DefaultSphere()
+			s1.Translate(0, 0, 0.5)
+			s2 := geom.DefaultSphere()
+			s2.Translate(0, 0, -0.5)
+			lens := geom.NewCSG(geom.CSGIntersection, &s1, &s2)
+			o := raytracer.NewObject(&lens, raytracer.DefaultMaterial())
+			o.Material.Transparency = 1.0
+			o.Material.IOR = 1.5
+			r := geom.NewRay(nmath.NewVec3(0, 0, -5), nmath.NewVec3(0, 0, 1))
+
+			xs := o.IntersectRay(r)
+			Expect(xs).To(HaveLen(2))
+			Expect(nmath.ApproxEq(xs[0].T, 4.5)).To(BeTrue())
+			Expect(nmath.ApproxEq(xs[1].T, 5.5)).To(BeTrue())
+
+			enter := xs[0].Precompute(r, xs)
+			Expect(enter.N1).To(Equal(1.0))
+			Expect(enter.N2).To(Equal(1.5))
+			Expect(enter.NormalV.ApproxEq(nmath.NewVec3(0, 0, -1))).To(BeTrue())
+
+			exit := xs[1].Precompute(r, xs)
+			Expect(exit.N1).To(Equal(1.5))
+			Expect(exit.N2).To(Equal(1.0))
+			Expect(exit.Inside).To(BeTrue())
+		})
+	})
+})
@@ -1,12 +1,208 @@
 package raytracer
 
-import "github.com/novelalex/soft-raytracer/pkg/nmath"
+import (
+	"math"
+	"math/rand/v2"
 
-type PointLight struct {
-	Position  nmath.Vec3
+	"github.com/novelalex/soft-raytracer/pkg/nmath"
+	"github.com/novelalex/soft-raytracer/pkg/sampling"
+)
+
+// Light is sampled at a number of points for each shading point, one shadow
+// ray is cast toward each of them and Material.Lighting averages their
+// contributions. A point light is the degenerate case with a single sample.
+type Light interface {
+	Samples(p nmath.Vec3) []LightSample
+}
+
+// LightSample is how one point of a light is seen from a shading point
+type LightSample struct {
+	// Direction from the shading point toward the light, normalized
+	Direction nmath.Vec3
+	// Distance to the light along Direction, +Inf for lights infinitely far away
+	Distance float64
+	// Intensity arriving at the shading point, as if the whole light was at this sample
 	Intensity nmath.Color
 }
 
+// newLightSample is the sample of a light at position as seen from p
+func newLightSample(p, position nmath.Vec3, intensity nmath.Color, attenuation Attenuation) LightSample {
+	v := position.Sub(p)
+	distance := v.Mag()
+	return LightSample{
+		v.Normalize(),
+		distance,
+		attenuation.apply(intensity, distance),
+	}
+}
+
+// Attenuation scales the intensity of a light by the distance it travels,
+// a nil Attenuation doesn't fall off at all
+type Attenuation func(distance float64) float64
+
+// InverseSquare is the physically correct falloff of a light
+func InverseSquare(distance float64) float64 {
+	return 1 / (distance * distance)
+}
+
+// QuadraticAttenuation is the classic 1 / (constant + linear*d + quadratic*d^2) curve
+func QuadraticAttenuation(constant, linear, quadratic float64) Attenuation {
+	return func(d float64) float64 {
+		return 1 / (constant + linear*d + quadratic*d*d)
+	}
+}
+
+func (a Attenuation) apply(intensity nmath.Color, distance float64) nmath.Color {
+	if a == nil {
+		return intensity
+	}
+	return intensity.AsVec3().Mult(a(distance)).AsColor()
+}
+
+type PointLight struct {
+	Position    nmath.Vec3
+	Intensity   nmath.Color
+	Attenuation Attenuation
+}
+
 func NewPointLight(position nmath.Vec3, intensity nmath.Color) PointLight {
-	return PointLight{position, intensity}
+	return PointLight{position, intensity, nil}
+}
+
+func (l PointLight) Samples(p nmath.Vec3) []LightSample {
+	return []LightSample{newLightSample(p, l.Position, l.Intensity, l.Attenuation)}
+}
+
+// DirectionalLight is infinitely far away, like the sun. Its rays all travel
+// along Direction and it is never attenuated.
+type DirectionalLight struct {
+	Direction nmath.Vec3
+	Intensity nmath.Color
+}
+
+func NewDirectionalLight(direction nmath.Vec3, intensity nmath.Color) DirectionalLight {
+	return DirectionalLight{direction.Normalize(), intensity}
+}
+
+func (l DirectionalLight) Samples(p nmath.Vec3) []LightSample {
+	return []LightSample{{l.Direction.Neg(), math.Inf(1), l.Intensity}}
+}
+
+// SpotLight is a point light that only shines in a cone around Direction.
+// It is at full intensity inside InnerAngle and fades out smoothly up to
+// OuterAngle, both measured from Direction in radians.
+type SpotLight struct {
+	Position    nmath.Vec3
+	Direction   nmath.Vec3
+	InnerAngle  float64
+	OuterAngle  float64
+	Intensity   nmath.Color
+	Attenuation Attenuation
+}
+
+func NewSpotLight(position, direction nmath.Vec3, inner, outer float64, intensity nmath.Color) SpotLight {
+	return SpotLight{position, direction.Normalize(), inner, outer, intensity, nil}
+}
+
+func (l SpotLight) Samples(p nmath.Vec3) []LightSample {
+	sample := newLightSample(p, l.Position, l.Intensity, l.Attenuation)
+	sample.Intensity = sample.Intensity.AsVec3().Mult(l.falloff(sample.Direction.Neg())).AsColor()
+	return []LightSample{sample}
+}
+
+// falloff is 1 inside the inner cone and 0 outside the outer cone,
+// with a smoothstep between them
+func (l SpotLight) falloff(dir nmath.Vec3) float64 {
+	cos := dir.Dot(l.Direction)
+	cos_inner := math.Cos(l.InnerAngle)
+	cos_outer := math.Cos(l.OuterAngle)
+	if cos >= cos_inner {
+		return 1
+	}
+	if cos <= cos_outer {
+		return 0
+	}
+	t := (cos - cos_outer) / (cos_inner - cos_outer)
+	return t * t * (3 - 2*t)
+}
+
+// RectLight is the parallelogram with a corner at Corner and the edges UVec
+// and VVec. It is split into a USteps by VSteps grid with one sample jittered
+// inside each cell.
+type RectLight struct {
+	Corner      nmath.Vec3
+	UVec        nmath.Vec3
+	VVec        nmath.Vec3
+	USteps      int
+	VSteps      int
+	Intensity   nmath.Color
+	Attenuation Attenuation
+}
+
+func NewRectLight(corner, uvec, vvec nmath.Vec3, usteps, vsteps int, intensity nmath.Color) RectLight {
+	return RectLight{corner, uvec, vvec, max(usteps, 1), max(vsteps, 1), intensity, nil}
+}
+
+func (l RectLight) Samples(p nmath.Vec3) []LightSample {
+	rng := shadingRand(p)
+	samples := make([]LightSample, 0, l.USteps*l.VSteps)
+	for v := range l.VSteps {
+		for u := range l.USteps {
+			su := (float64(u) + rng.Float64()) / float64(l.USteps)
+			sv := (float64(v) + rng.Float64()) / float64(l.VSteps)
+			position := l.Corner.Add(l.UVec.Mult(su)).Add(l.VVec.Mult(sv))
+			samples = append(samples, newLightSample(p, position, l.Intensity, l.Attenuation))
+		}
+	}
+	return samples
+}
+
+// SphereLight is a sphere that glows evenly. From any shading point it looks
+// like a disk facing that point, which is what is sampled.
+type SphereLight struct {
+	Center      nmath.Vec3
+	Radius      float64
+	NumSamples  int
+	Intensity   nmath.Color
+	Attenuation Attenuation
+}
+
+func NewSphereLight(center nmath.Vec3, radius float64, samples int, intensity nmath.Color) SphereLight {
+	return SphereLight{center, radius, max(samples, 1), intensity, nil}
+}
+
+func (l SphereLight) Samples(p nmath.Vec3) []LightSample {
+	// two axes across the disk facing p
+	u, v := orthonormalBasis(p.Sub(l.Center).Normalize())
+
+	points := sampling.Stratified{}.Samples(l.NumSamples, shadingRand(p))
+	samples := make([]LightSample, len(points))
+	for i, point := range points {
+		d := sampling.ConcentricDisk(point)
+		position := l.Center.
+			Add(u.Mult(d.X * l.Radius)).
+			Add(v.Mult(d.Y * l.Radius))
+		samples[i] = newLightSample(p, position, l.Intensity, l.Attenuation)
+	}
+	return samples
+}
+
+// shadingRand is seeded by the shading point, so the jitter changes across
+// a surface instead of banding but a point is always lit the same way
+func shadingRand(p nmath.Vec3) *rand.Rand {
+	h := math.Float64bits(p.X)
+	h = h*0x9e3779b97f4a7c15 ^ math.Float64bits(p.Y)
+	h = h*0x9e3779b97f4a7c15 ^ math.Float64bits(p.Z)
+	return rand.New(rand.NewPCG(h, 0x5851f42d4c957f2d))
+}
+
+// orthonormalBasis returns two unit vectors perpendicular to n and each other
+func orthonormalBasis(n nmath.Vec3) (nmath.Vec3, nmath.Vec3) {
+	helper := nmath.NewVec3(1, 0, 0)
+	if math.Abs(n.X) > 0.9 {
+		helper = nmath.NewVec3(0, 1, 0)
+	}
+	u := helper.Cross(n).Normalize()
+	v := n.Cross(u)
+	return u, v
 }
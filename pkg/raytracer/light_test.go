@@ -0,0 +1,165 @@
+package raytracer_test
+
+import (
+	"math"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/novelalex/soft-raytracer/pkg/geom"
+	"github.com/novelalex/soft-raytracer/pkg/nmath"
+	"github.com/novelalex/soft-raytracer/pkg/raytracer"
+)
+
+var _ = Describe("Light", func() {
+	white := nmath.NewColor(1, 1, 1)
+	position := func(p nmath.Vec3, s raytracer.LightSample) nmath.Vec3 {
+		return p.Add(s.Direction.Mult(s.Distance))
+	}
+
+	Describe("PointLight", func() {
+		It("should have a single sample at its position", func() {
+			l := raytracer.NewPointLight(nmath.NewVec3(1, 2, 4), white)
+			samples := l.Samples(nmath.NewVec3(1, 2, 0))
+			Expect(samples).To(HaveLen(1))
+			Expect(samples[0].Direction.ApproxEq(nmath.NewVec3(0, 0, 1))).To(BeTrue())
+			Expect(samples[0].Distance).To(BeNumerically("~", 4))
+			Expect(samples[0].Intensity).To(Equal(white))
+		})
+
+		It("should be attenuated by distance", func() {
+			l := raytracer.NewPointLight(nmath.NewVec3(0, 0, 4), white)
+			l.Attenuation = raytracer.InverseSquare
+			Expect(l.Samples(nmath.NewVec3(0, 0, 0))[0].Intensity.AsVec3().ApproxEq(nmath.NewVec3(1.0/16, 1.0/16, 1.0/16))).To(BeTrue())
+
+			l.Attenuation = raytracer.QuadraticAttenuation(1, 0.5, 0)
+			Expect(l.Samples(nmath.NewVec3(0, 0, 0))[0].Intensity.R).To(BeNumerically("~", 1.0/3))
+		})
+	})
+
+	Describe("DirectionalLight", func() {
+		It("should be infinitely far away", func() {
+			l := raytracer.NewDirectionalLight(nmath.NewVec3(0, -2, 0), white)
+			samples := l.Samples(nmath.NewVec3(5, 0, 5))
+			Expect(samples[0].Direction.ApproxEq(nmath.NewVec3(0, 1, 0))).To(BeTrue())
+			Expect(math.IsInf(samples[0].Distance, 1)).To(BeTrue())
+		})
+
+		It("should be shadowed by objects however far away they are", func() {
+			w := raytracer.NewWorld()
+			sun := raytracer.NewDirectionalLight(nmath.NewVec3(0, -1, 0), white)
+			in_shadow, _ := w.IsShadowed(nmath.NewVec3(0, -1000, 0), sun)
+			Expect(in_shadow).To(BeTrue())
+			in_shadow, _ = w.IsShadowed(nmath.NewVec3(5, -1000, 0), sun)
+			Expect(in_shadow).To(BeFalse())
+		})
+	})
+
+	Describe("SpotLight", func() {
+		l := raytracer.NewSpotLight(nmath.NewVec3(0, 10, 0), nmath.NewVec3(0, -1, 0), math.Pi/8, math.Pi/4, white)
+		intensity := func(p nmath.Vec3) float64 {
+			return l.Samples(p)[0].Intensity.R
+		}
+
+		It("should be at full intensity inside the inner cone", func() {
+			Expect(intensity(nmath.NewVec3(0, 0, 0))).To(Equal(1.0))
+			Expect(intensity(nmath.NewVec3(1, 0, 0))).To(Equal(1.0))
+		})
+
+		It("should fade out between the cones", func() {
+			i := intensity(nmath.NewVec3(7, 0, 0))
+			Expect(i).To(BeNumerically(">", 0))
+			Expect(i).To(BeNumerically("<", 1))
+		})
+
+		It("should be dark outside the outer cone", func() {
+			Expect(intensity(nmath.NewVec3(11, 0, 0))).To(Equal(0.0))
+		})
+	})
+
+	Describe("RectLight", func() {
+		l := raytracer.NewRectLight(nmath.NewVec3(0, 5, 0), nmath.NewVec3(2, 0, 0), nmath.NewVec3(0, 0, 1), 4, 2, white)
+
+		It("should have one sample in each cell of the grid", func() {
+			p := nmath.NewVec3(0, 0, 0)
+			samples := l.Samples(p)
+			Expect(samples).To(HaveLen(8))
+			for i, sample := range samples {
+				s := position(p, sample)
+				u, v := i%4, i/4
+				Expect(s.X).To(BeNumerically(">=", float64(u)*0.5-nmath.F64Epsilon))
+				Expect(s.X).To(BeNumerically("<=", float64(u+1)*0.5+nmath.F64Epsilon))
+				Expect(s.Z).To(BeNumerically(">=", float64(v)*0.5-nmath.F64Epsilon))
+				Expect(s.Z).To(BeNumerically("<=", float64(v+1)*0.5+nmath.F64Epsilon))
+				Expect(s.Y).To(BeNumerically("~", 5, nmath.F64Epsilon))
+			}
+		})
+
+		It("should sample the same points for the same shading point", func() {
+			p := nmath.NewVec3(0.25, -1, 3)
+			Expect(l.Samples(p)).To(Equal(l.Samples(p)))
+		})
+	})
+
+	Describe("SphereLight", func() {
+		It("should sample a disk facing the shading point", func() {
+			l := raytracer.NewSphereLight(nmath.NewVec3(0, 5, 0), 0.5, 16, white)
+			p := nmath.NewVec3(0, 0, 0)
+			samples := l.Samples(p)
+			Expect(samples).To(HaveLen(16))
+			for _, sample := range samples {
+				s := position(p, sample)
+				Expect(s.Y).To(BeNumerically("~", 5, nmath.F64Epsilon))
+				Expect(s.Sub(l.Center).Mag()).To(BeNumerically("<=", 0.5+nmath.F64Epsilon))
+			}
+		})
+	})
+
+	Describe("Soft shadows", func() {
+		It("should partially shadow a point in the penumbra", func() {
+			w := raytracer.NewWorld()
+			light := raytracer.NewRectLight(nmath.NewVec3(0, 10, -0.5), nmath.NewVec3(16, 0, 0), nmath.NewVec3(0, 0, 1), 8, 2, white)
+
+			in_shadow, strength := w.IsShadowed(nmath.NewVec3(0, -2, 0), light)
+			Expect(in_shadow).To(BeTrue())
+			Expect(strength).To(BeNumerically(">", 0))
+			Expect(strength).To(BeNumerically("<", 1))
+		})
+
+		It("should average the lighting of the visible samples", func() {
+			m := raytracer.DefaultMaterial()
+			s := geom.DefaultSphere()
+			p := nmath.NewVec3(0, 0, 0)
+			eye := nmath.NewVec3(0, 0, -1)
+			normal := nmath.NewVec3(0, 0, -1)
+			samples := []raytracer.LightSample{
+				{Direction: nmath.NewVec3(0, 0, -1), Distance: 10, Intensity: white},
+				{Direction: nmath.NewVec3(0, 0, -1), Distance: 10, Intensity: white},
+			}
+
+			lit := m.Lighting(&s, samples, p, eye, normal, []float64{1, 1})
+			half := m.Lighting(&s, samples, p, eye, normal, []float64{1, 0})
+			dark := m.Lighting(&s, samples, p, eye, normal, []float64{0, 0})
+
+			Expect(lit.AsVec3().ApproxEq(nmath.NewVec3(1.9, 1.9, 1.9))).To(BeTrue())
+			Expect(half.AsVec3().ApproxEq(nmath.NewVec3(1, 1, 1))).To(BeTrue())
+			Expect(dark.AsVec3().ApproxEq(nmath.NewVec3(0.1, 0.1, 0.1))).To(BeTrue())
+		})
+
+		It("should count samples without a visibility as visible", func() {
+			m := raytracer.DefaultMaterial()
+			s := geom.DefaultSphere()
+			normal := nmath.NewVec3(0, 0, -1)
+			samples := []raytracer.LightSample{
+				{Direction: normal, Distance: 10, Intensity: white},
+				{Direction: normal, Distance: 10, Intensity: white},
+			}
+
+			c := m.Lighting(&s, samples, nmath.NewVec3(0, 0, 0), normal, normal, []float64{0})
+
+			Expect(c.AsVec3().ApproxEq(nmath.NewVec3(1, 1, 1))).To(BeTrue())
+			Expect(m.Lighting(&s, samples, nmath.NewVec3(0, 0, 0), normal, normal, nil)).
+				To(Equal(m.Lighting(&s, samples, nmath.NewVec3(0, 0, 0), normal, normal, []float64{1, 1})))
+		})
+	})
+})
@@ -17,6 +17,13 @@ type Material struct {
 	Transparency float64
 	IOR          float64
 	Pattern      geom.Pattern
+	// Emission is the light the surface gives off by itself
+	Emission Color
+	// Bump changes the normal used for shading, nil keeps the geometric normal
+	Bump geom.Bump
+	// PBR shades the surface with a microfacet model instead of Phong,
+	// nil keeps Phong and its Diffuse, Specular and Shininess
+	PBR *PBR
 }
 
 func NewMaterial(color Color, ambient, diffuse, specular, shininess, reflective, transparency, ior float64) Material {
@@ -30,6 +37,9 @@ func NewMaterial(color Color, ambient, diffuse, specular, shininess, reflective,
 		transparency,
 		ior,
 		nil,
+		NewColor(0, 0, 0),
+		nil,
+		nil,
 	}
 }
 
@@ -44,48 +54,89 @@ func DefaultMaterial() Material {
 		0.0,
 		1.0,
 		nil,
+		NewColor(0, 0, 0),
+		nil,
+		nil,
 	}
 }
 
-func (m Material) Lighting(s geom.Shape, l PointLight, p, eye, normal Vec3, in_shadow bool, shadow_strength float64) Color {
-	ambient := NewColor(0, 0, 0)
-	diffuse := NewColor(0, 0, 0)
-	specular := NewColor(0, 0, 0)
+// Lighting is the Phong shading of p by the samples of one light, averaged
+// over the samples. visibility holds how much of each sample reaches p, from 0
+// in full shadow to 1, a sample without an entry is fully visible. A sample in
+// shadow only gets the ambient term scaled by the strength of the shadow, so a
+// point light is shaded the same way whatever shadows it.
+func (m Material) Lighting(s geom.Shape, samples []LightSample, p, eye, normal Vec3, visibility []float64) Color {
+	if len(samples) == 0 {
+		return NewColor(0, 0, 0)
+	}
+	color := m.colorAt(s, p)
 
-	var color Color
-	if m.Pattern != nil {
-		color = m.Pattern.AtObject(s, p)
-	} else {
-		color = m.Color
+	sum := NewVec3(0, 0, 0)
+	for i, sample := range samples {
+		ambient := color.HadamardMult(sample.Intensity).AsVec3().Mult(m.Ambient)
+		shadow_strength := 1.0 - visibilityAt(visibility, i)
+		if shadow_strength > F64EpsilonLoose {
+			sum = sum.Add(ambient.Mult(shadow_strength))
+			continue
+		}
+		sum = sum.Add(ambient).Add(m.direct(color, sample, eye, normal))
 	}
+	return sum.Mult(1 / float64(len(samples))).AsColor()
+}
 
-	effective_color := color.HadamardMult(l.Intensity)
+// directLighting is the diffuse and specular light the samples bring to p
+// without the ambient term. Each sample is dimmed by how much of it gets
+// through instead of being shadowed, for the path tracer.
+func (m Material) directLighting(s geom.Shape, samples []LightSample, p, eye, normal Vec3, visibility []float64) Color {
+	if len(samples) == 0 {
+		return NewColor(0, 0, 0)
+	}
+	color := m.colorAt(s, p)
 
-	light_v := l.Position.Sub(p).Normalize()
-	ambient = effective_color.AsVec3().Mult(m.Ambient).AsColor()
+	sum := NewVec3(0, 0, 0)
+	for i, sample := range samples {
+		if v := visibilityAt(visibility, i); v > 0 {
+			sum = sum.Add(m.direct(color, sample, eye, normal).Mult(v))
+		}
+	}
+	return sum.Mult(1 / float64(len(samples))).AsColor()
+}
 
-	if in_shadow {
-		return ambient.AsVec3().Mult(shadow_strength).AsColor()
+// direct is the diffuse and specular light of a sample that reaches p
+func (m Material) direct(color Color, sample LightSample, eye, normal Vec3) Vec3 {
+	if m.PBR != nil {
+		return m.PBR.direct(color, sample, eye, normal)
 	}
 
+	light_v := sample.Direction
 	light_dot_normal := light_v.Dot(normal)
 	if light_dot_normal < 0 {
-		diffuse = NewColor(0, 0, 0)
-		specular = NewColor(0, 0, 0)
-	} else {
-		diffuse = effective_color.AsVec3().Mult(m.Diffuse * light_dot_normal).AsColor()
-		reflect_v := light_v.Neg().Reflect(normal)
-		reflect_dot_eye := reflect_v.Dot(eye)
-		if reflect_dot_eye <= 0 {
-			specular = NewColor(0, 0, 0)
-		} else {
-			factor := math.Pow(reflect_dot_eye, m.Shininess)
-			specular = l.Intensity.AsVec3().Mult(m.Specular * factor).AsColor()
-		}
+		return NewVec3(0, 0, 0)
+	}
+
+	effective_color := color.HadamardMult(sample.Intensity)
+	diffuse := effective_color.AsVec3().Mult(m.Diffuse * light_dot_normal)
+	specular := NewVec3(0, 0, 0)
+	reflect_v := light_v.Neg().Reflect(normal)
+	reflect_dot_eye := reflect_v.Dot(eye)
+	if reflect_dot_eye > 0 {
+		factor := math.Pow(reflect_dot_eye, m.Shininess)
+		specular = sample.Intensity.AsVec3().Mult(m.Specular * factor)
+	}
+	return diffuse.Add(specular)
+}
+
+// visibilityAt is visibility[i], a sample past the end of visibility is fully visible
+func visibilityAt(visibility []float64, i int) float64 {
+	if i < len(visibility) {
+		return visibility[i]
 	}
+	return 1
+}
 
-	return ambient.AsVec3().
-		Add(diffuse.AsVec3()).
-		Add(specular.AsVec3()).
-		AsColor()
+func (m Material) colorAt(s geom.Shape, p Vec3) Color {
+	if m.Pattern != nil {
+		return m.Pattern.AtObject(s, p)
+	}
+	return m.Color
 }
@@ -30,8 +30,8 @@ func NewGlassSphere() Object {
 func (o *Object) IntersectRay(r geom.Ray) Intersections {
 	xs := Intersections{}
 	geom_intersects := o.Shape.IntersectRay(r)
-	for _, intersect := range geom_intersects {
-		xs = append(xs, NewIntersection(intersect, o))
+	for _, hit := range geom_intersects {
+		xs = append(xs, newIntersectionFromHit(hit, o))
 	}
 	return xs
 }
@@ -0,0 +1,162 @@
+package raytracer
+
+import (
+	"math"
+	"math/rand/v2"
+
+	. "github.com/novelalex/soft-raytracer/pkg/nmath"
+)
+
+// PBR is a metallic/roughness microfacet model: a GGX distribution of
+// microfacet normals with Smith masking and Schlick's Fresnel, over a
+// lambertian base that only gets the light the specular layer lets through.
+// The base color comes from the Color or Pattern of the material.
+type PBR struct {
+	// Metallic goes from a dielectric, with a white specular highlight over
+	// the colored base, to a metal with a highlight tinted by the base and no
+	// diffuse at all
+	Metallic float64
+	// Roughness goes from a mirror-like 0 to 1, where highlights spread over
+	// the whole hemisphere
+	Roughness float64
+}
+
+// NewPBRMaterial is the default material shaded with the PBR model
+func NewPBRMaterial(color Color, metallic, roughness float64) Material {
+	m := DefaultMaterial()
+	m.Color = color
+	m.PBR = &PBR{metallic, roughness}
+	return m
+}
+
+// dielectricF0 is the reflectance at normal incidence of common dielectrics,
+// an index of refraction of about 1.5
+const dielectricF0 = 0.04
+
+// minRoughness keeps the highlight of a point light from becoming infinitely small and bright
+const minRoughness = 0.03
+
+func (p PBR) alpha() float64 {
+	r := max(minRoughness, min(p.Roughness, 1))
+	return r * r
+}
+
+// f0 is the Fresnel reflectance at normal incidence for a base color
+func (p PBR) f0(base Color) Color {
+	return lerpColor(NewColor(dielectricF0, dielectricF0, dielectricF0), base, p.Metallic)
+}
+
+// eval is the BRDF times pi for light arriving from light_v and leaving toward eye.
+// Lights give their intensity as the light a surface facing them receives, the
+// pi makes a white lambertian surface facing a light as bright as the light.
+func (p PBR) eval(base Color, normal, eye, light_v Vec3) Color {
+	n_dot_l := normal.Dot(light_v)
+	n_dot_v := normal.Dot(eye)
+	if n_dot_l <= 0 || n_dot_v <= 0 {
+		return NewColor(0, 0, 0)
+	}
+	half := eye.Add(light_v).Normalize()
+	fresnel := fresnelSchlick(p.f0(base), eye.Dot(half))
+
+	a := p.alpha()
+	d := ggxD(normal.Dot(half), a)
+	g := smithG1(n_dot_v, a) * smithG1(n_dot_l, a)
+	specular := fresnel.AsVec3().Mult(math.Pi * d * g / (4 * n_dot_l * n_dot_v))
+
+	return p.diffuse(base, fresnel).AsVec3().Add(specular).AsColor()
+}
+
+// diffuse is the albedo of the base, what the specular layer doesn't reflect
+// and a metal doesn't absorb
+func (p PBR) diffuse(base, fresnel Color) Color {
+	return Color{
+		R: base.R * (1 - fresnel.R) * (1 - p.Metallic),
+		G: base.G * (1 - fresnel.G) * (1 - p.Metallic),
+		B: base.B * (1 - fresnel.B) * (1 - p.Metallic),
+		A: 1,
+	}
+}
+
+// sample picks the direction a path continues in from a surface seen from eye.
+// weight is the BRDF times the cosine over the probability of the direction,
+// false means the path ends.
+func (p PBR) sample(base Color, normal, eye Vec3, rng *rand.Rand) (direction Vec3, weight Color, ok bool) {
+	n_dot_v := normal.Dot(eye)
+	if n_dot_v <= 0 {
+		return Vec3{}, Color{}, false
+	}
+	a := p.alpha()
+
+	// metals have no diffuse lobe, dielectrics split their paths evenly
+	specular_probability := 0.5 + 0.5*p.Metallic
+	if rng.Float64() < specular_probability {
+		half := ggxHalfVector(normal, a, rng)
+		direction = eye.Neg().Reflect(half)
+		n_dot_l := normal.Dot(direction)
+		if n_dot_l <= 0 {
+			return Vec3{}, Color{}, false
+		}
+		v_dot_h := eye.Dot(half)
+		fresnel := fresnelSchlick(p.f0(base), v_dot_h)
+		g := smithG1(n_dot_v, a) * smithG1(n_dot_l, a)
+		// D cancels with the pdf of the half vector, D n.h / (4 v.h)
+		scale := g * v_dot_h / (n_dot_v * normal.Dot(half)) / specular_probability
+		return direction, fresnel.AsVec3().Mult(scale).AsColor(), true
+	}
+
+	direction = cosineDirection(normal, rng)
+	half := eye.Add(direction).Normalize()
+	fresnel := fresnelSchlick(p.f0(base), eye.Dot(half))
+	return direction, scaleColor(p.diffuse(base, fresnel), 1/(1-specular_probability)), true
+}
+
+// ggxD is the GGX (Trowbridge-Reitz) distribution of microfacet normals
+func ggxD(n_dot_h, a float64) float64 {
+	a2 := a * a
+	d := n_dot_h*n_dot_h*(a2-1) + 1
+	return a2 / (math.Pi * d * d)
+}
+
+// smithG1 is the fraction of microfacets seen from a direction at cos_theta to
+// the normal that aren't hidden behind others
+func smithG1(cos_theta, a float64) float64 {
+	a2 := a * a
+	return 2 * cos_theta / (cos_theta + math.Sqrt(a2+(1-a2)*cos_theta*cos_theta))
+}
+
+// fresnelSchlick approximates the reflectance at an angle with cos_theta
+// to the normal from the reflectance f0 at normal incidence
+func fresnelSchlick(f0 Color, cos_theta float64) Color {
+	f := math.Pow(1-max(0, min(cos_theta, 1)), 5)
+	return Color{
+		R: f0.R + (1-f0.R)*f,
+		G: f0.G + (1-f0.G)*f,
+		B: f0.B + (1-f0.B)*f,
+		A: 1,
+	}
+}
+
+// ggxHalfVector is a microfacet normal around normal picked in proportion to D n.h
+func ggxHalfVector(normal Vec3, a float64, rng *rand.Rand) Vec3 {
+	u1, u2 := rng.Float64(), rng.Float64()
+	cos_theta := math.Sqrt((1 - u1) / (1 + (a*a-1)*u1))
+	sin_theta := math.Sqrt(max(0, 1-cos_theta*cos_theta))
+	phi := 2 * math.Pi * u2
+	u, v := orthonormalBasis(normal)
+	return u.Mult(sin_theta * math.Cos(phi)).Add(v.Mult(sin_theta * math.Sin(phi))).Add(normal.Mult(cos_theta))
+}
+
+func lerpColor(a, b Color, t float64) Color {
+	return a.AsVec3().Mult(1 - t).Add(b.AsVec3().Mult(t)).AsColor()
+}
+
+// direct is the light of a sample reaching a surface with this model,
+// Lighting adds the same ambient term as Phong's so both fit in the same scene
+func (p PBR) direct(base Color, sample LightSample, eye, normal Vec3) Vec3 {
+	light_dot_normal := sample.Direction.Dot(normal)
+	if light_dot_normal <= 0 {
+		return NewVec3(0, 0, 0)
+	}
+	f := p.eval(base, normal, eye, sample.Direction)
+	return f.HadamardMult(sample.Intensity).AsVec3().Mult(light_dot_normal)
+}
@@ -0,0 +1,130 @@
+package raytracer_test
+
+import (
+	"math"
+	"math/rand/v2"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/novelalex/soft-raytracer/pkg/geom"
+	"github.com/novelalex/soft-raytracer/pkg/nmath"
+	"github.com/novelalex/soft-raytracer/pkg/raytracer"
+)
+
+var _ = Describe("PBR", func() {
+	s := geom.DefaultSphere()
+	p := nmath.NewVec3(0, 0, -1)
+	normal := nmath.NewVec3(0, 0, -1)
+	white := nmath.NewColor(1, 1, 1)
+	head_on := []raytracer.LightSample{{Direction: normal, Distance: 10, Intensity: white}}
+
+	It("should keep the Phong knobs of the default material", func() {
+		m := raytracer.NewPBRMaterial(nmath.NewColor(1, 0, 0), 0.5, 0.2)
+
+		Expect(m.Color).To(Equal(nmath.NewColor(1, 0, 0)))
+		Expect(*m.PBR).To(Equal(raytracer.PBR{Metallic: 0.5, Roughness: 0.2}))
+		Expect(m.Ambient).To(Equal(raytracer.DefaultMaterial().Ambient))
+	})
+
+	It("should light a rough dielectric facing the light", func() {
+		m := raytracer.NewPBRMaterial(white, 0, 1)
+
+		c := m.Lighting(&s, head_on, p, normal, normal, []float64{1})
+
+		// diffuse 1 - F0 = 0.96, specular D G F / 4 times pi = 0.01, ambient 0.1
+		Expect(c.AsVec3().ApproxEq(nmath.NewVec3(1.07, 1.07, 1.07))).To(BeTrue())
+	})
+
+	It("should tint the highlight of a metal by its color and have no diffuse", func() {
+		m := raytracer.NewPBRMaterial(nmath.NewColor(1, 0.5, 0), 1, 1)
+		m.Ambient = 0
+
+		c := m.Lighting(&s, head_on, p, normal, normal, []float64{1})
+
+		Expect(c.AsVec3().ApproxEq(nmath.NewVec3(0.25, 0.125, 0))).To(BeTrue())
+	})
+
+	It("should make smooth highlights brighter and narrower", func() {
+		rough := raytracer.NewPBRMaterial(white, 1, 0.8)
+		smooth := raytracer.NewPBRMaterial(white, 1, 0.2)
+		rough.Ambient, smooth.Ambient = 0, 0
+		off_axis := []raytracer.LightSample{{Direction: nmath.NewVec3(0.5, 0, -1).Normalize(), Distance: 10, Intensity: white}}
+
+		Expect(smooth.Lighting(&s, head_on, p, normal, normal, []float64{1}).R).
+			To(BeNumerically(">", rough.Lighting(&s, head_on, p, normal, normal, []float64{1}).R))
+		Expect(smooth.Lighting(&s, off_axis, p, normal, normal, []float64{1}).R).
+			To(BeNumerically("<", rough.Lighting(&s, off_axis, p, normal, normal, []float64{1}).R))
+	})
+
+	DescribeTable("should not reflect more light than it receives",
+		func(metallic, roughness float64) {
+			m := raytracer.NewPBRMaterial(white, metallic, roughness)
+			m.Ambient = 0
+
+			// light from a grid over the hemisphere, the intensity of 2 makes
+			// the average over the samples the integral of the BRDF times the cosine
+			var samples []raytracer.LightSample
+			const n = 200
+			for i := range n {
+				for j := range n {
+					cos_theta := (float64(i) + 0.5) / n
+					phi := 2 * math.Pi * (float64(j) + 0.5) / n
+					sin_theta := math.Sqrt(1 - cos_theta*cos_theta)
+					samples = append(samples, raytracer.LightSample{
+						Direction: nmath.NewVec3(sin_theta*math.Cos(phi), sin_theta*math.Sin(phi), -cos_theta),
+						Distance:  10,
+						Intensity: nmath.NewColor(2, 2, 2),
+					})
+				}
+			}
+			visibility := make([]float64, len(samples))
+			for i := range visibility {
+				visibility[i] = 1
+			}
+
+			for _, eye := range []nmath.Vec3{normal, nmath.NewVec3(0.6, 0, -0.8)} {
+				c := m.Lighting(&s, samples, p, eye, normal, visibility)
+				Expect(c.R).To(BeNumerically("<=", 1.01))
+				Expect(c.R).To(BeNumerically(">", 0.25))
+			}
+		},
+		Entry("rough dielectric", 0.0, 1.0),
+		Entry("smooth dielectric", 0.0, 0.3),
+		Entry("rough metal", 1.0, 1.0),
+		Entry("half metal", 0.5, 0.6),
+	)
+
+	It("should not let a path tracer gain energy inside a white furnace", func() {
+		furnace_shape := geom.DefaultSphere()
+		furnace_shape.SetTransform(nmath.NewScaling(10, 10, 10))
+		furnace := raytracer.NewObject(&furnace_shape, raytracer.DefaultMaterial())
+		furnace.Material.Diffuse = 0
+		furnace.Material.Emission = white
+
+		ball_shape := geom.DefaultSphere()
+		ball := raytracer.NewObject(&ball_shape, raytracer.NewPBRMaterial(white, 0, 0.5))
+		w := raytracer.NewWorldWith([]raytracer.Light{}, []raytracer.Object{furnace, ball})
+
+		r := geom.NewRay(nmath.NewVec3(0, 0, -5), nmath.NewVec3(0, 0, 1))
+		rng := rand.New(rand.NewPCG(1, 2))
+		pt := raytracer.PathTracer{MaxDepth: 2}
+		sum := 0.0
+		const runs = 20000
+		for range runs {
+			sum += pt.Li(&w, r, rng).R
+		}
+		Expect(sum / runs).To(BeNumerically("~", 0.95, 0.06))
+	})
+
+	It("should leave Phong materials alone", func() {
+		m := raytracer.DefaultMaterial()
+		pbr := m
+		pbr.PBR = &raytracer.PBR{}
+
+		phong := m.Lighting(&s, head_on, p, normal, normal, []float64{1})
+
+		Expect(phong.AsVec3().ApproxEq(nmath.NewVec3(1.9, 1.9, 1.9))).To(BeTrue())
+		Expect(pbr.Lighting(&s, head_on, p, normal, normal, []float64{1})).NotTo(Equal(phong))
+	})
+})
@@ -0,0 +1,101 @@
+package raytracer
+
+import (
+	"math"
+
+	"github.com/novelalex/soft-raytracer/pkg/geom"
+	"github.com/novelalex/soft-raytracer/pkg/nmath"
+)
+
+// Projection maps the continuous image position (x, y) of a width by height
+// image to a ray in camera space, where the camera is at the origin looking
+// down -z with +y up. Image x grows toward camera -x, the view transform
+// from LookAt flips it back. The direction doesn't have to be normalized.
+// It returns false for positions the projection doesn't cover.
+type Projection interface {
+	CameraRay(x, y float64, width, height uint) (geom.Ray, bool)
+}
+
+// perspectiveView is the half size of the image on the plane one unit in
+// front of the camera for a field of view across the longer side
+func perspectiveView(fov float64, width, height uint) (float64, float64, float64) {
+	half_view := fov / 2.0
+	aspect := float64(width) / float64(height)
+	var half_width, half_height float64
+	if aspect >= 1 {
+		half_width = half_view
+		half_height = half_view / aspect
+	} else {
+		half_width = half_view * aspect
+		half_height = half_view
+	}
+	return half_width, half_height, (half_width * 2.0) / float64(width)
+}
+
+// Perspective is a pinhole projection, FOV is the field of view across the
+// longer side of the image
+type Perspective struct {
+	FOV float64
+}
+
+func (p Perspective) CameraRay(x, y float64, width, height uint) (geom.Ray, bool) {
+	half_width, half_height, pixel_size := perspectiveView(p.FOV, width, height)
+	world_x := half_width - x*pixel_size
+	world_y := half_height - y*pixel_size
+	return geom.NewRay(nmath.NewVec3(0, 0, 0), nmath.NewVec3(world_x, world_y, -1)), true
+}
+
+// Orthographic sends parallel rays from a plane through the camera,
+// Width is the size of the view across the image in world units
+type Orthographic struct {
+	Width float64
+}
+
+func (o Orthographic) CameraRay(x, y float64, width, height uint) (geom.Ray, bool) {
+	pixel_size := o.Width / float64(width)
+	world_x := o.Width/2 - x*pixel_size
+	world_y := pixel_size*float64(height)/2 - y*pixel_size
+	return geom.NewRay(nmath.NewVec3(world_x, world_y, 0), nmath.NewVec3(0, 0, -1)), true
+}
+
+// Fisheye is an equidistant fisheye, the angle from the view direction grows
+// linearly with the distance from the center of the image. FOV is the angle
+// covered by the circle that fits the shorter side, positions outside it
+// aren't covered.
+type Fisheye struct {
+	FOV float64
+}
+
+func (f Fisheye) CameraRay(x, y float64, width, height uint) (geom.Ray, bool) {
+	radius := float64(min(width, height)) / 2
+	nx := (float64(width)/2 - x) / radius
+	ny := (float64(height)/2 - y) / radius
+	r := math.Hypot(nx, ny)
+	if r > 1 {
+		return geom.Ray{}, false
+	}
+
+	theta := r * f.FOV / 2
+	phi := math.Atan2(ny, nx)
+	direction := nmath.NewVec3(
+		math.Sin(theta)*math.Cos(phi),
+		math.Sin(theta)*math.Sin(phi),
+		-math.Cos(theta),
+	)
+	return geom.NewRay(nmath.NewVec3(0, 0, 0), direction), true
+}
+
+// Equirectangular covers every direction around the camera, longitude across
+// the image and latitude down it, for 360 degree panoramas with a 2:1 aspect
+type Equirectangular struct{}
+
+func (Equirectangular) CameraRay(x, y float64, width, height uint) (geom.Ray, bool) {
+	longitude := (x/float64(width))*2*math.Pi - math.Pi
+	latitude := math.Pi/2 - (y/float64(height))*math.Pi
+	direction := nmath.NewVec3(
+		-math.Cos(latitude)*math.Sin(longitude),
+		math.Sin(latitude),
+		-math.Cos(latitude)*math.Cos(longitude),
+	)
+	return geom.NewRay(nmath.NewVec3(0, 0, 0), direction), true
+}
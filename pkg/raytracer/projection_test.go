@@ -0,0 +1,87 @@
+package raytracer_test
+
+import (
+	"math"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/novelalex/soft-raytracer/pkg/nmath"
+	"github.com/novelalex/soft-raytracer/pkg/raytracer"
+)
+
+var _ = Describe("Projection", func() {
+	direction := func(p raytracer.Projection, x, y float64, w, h uint) nmath.Vec3 {
+		r, ok := p.CameraRay(x, y, w, h)
+		Expect(ok).To(BeTrue())
+		return r.Dir.Normalize()
+	}
+
+	Describe("Perspective", func() {
+		It("should match the camera's rays", func() {
+			c := raytracer.NewCamera(201, 101, math.Pi/2.0)
+			c.Transform = nmath.NewRotationY(math.Pi / 4).Mult(nmath.NewTranslation(0, -2, 5))
+			expected := c.RayForPixel(0, 0)
+
+			c.Projection = raytracer.Perspective{FOV: math.Pi / 2.0}
+			r := c.RayForPixel(0, 0)
+			Expect(r.ApproxEq(expected)).To(BeTrue())
+		})
+
+		It("should look straight ahead through the center", func() {
+			d := direction(raytracer.Perspective{FOV: math.Pi / 2.0}, 100.5, 50.5, 201, 101)
+			Expect(d.ApproxEq(nmath.NewVec3(0, 0, -1))).To(BeTrue())
+		})
+	})
+
+	Describe("Orthographic", func() {
+		It("should send parallel rays from a plane", func() {
+			p := raytracer.Orthographic{Width: 4}
+			r, _ := p.CameraRay(0, 0, 100, 50)
+			Expect(r.Origin.ApproxEq(nmath.NewVec3(2, 1, 0))).To(BeTrue())
+			Expect(r.Dir.ApproxEq(nmath.NewVec3(0, 0, -1))).To(BeTrue())
+
+			r, _ = p.CameraRay(50, 25, 100, 50)
+			Expect(r.Origin.ApproxEq(nmath.NewVec3(0, 0, 0))).To(BeTrue())
+			Expect(r.Dir.ApproxEq(nmath.NewVec3(0, 0, -1))).To(BeTrue())
+		})
+	})
+
+	Describe("Fisheye", func() {
+		p := raytracer.Fisheye{FOV: math.Pi}
+
+		It("should look straight ahead through the center", func() {
+			Expect(direction(p, 50, 50, 100, 100).ApproxEq(nmath.NewVec3(0, 0, -1))).To(BeTrue())
+		})
+
+		It("should reach half the field of view at the edge of the circle", func() {
+			Expect(direction(p, 0, 50, 100, 100).ApproxEq(nmath.NewVec3(1, 0, 0))).To(BeTrue())
+			Expect(direction(p, 50, 0, 100, 100).ApproxEq(nmath.NewVec3(0, 1, 0))).To(BeTrue())
+		})
+
+		It("should not cover the corners of the image", func() {
+			_, ok := p.CameraRay(1, 1, 100, 100)
+			Expect(ok).To(BeFalse())
+		})
+
+		It("should render the corners black", func() {
+			c := raytracer.NewCamera(11, 11, math.Pi/2.0)
+			c.Transform = nmath.NewVec3(0, 0, -5).LookAt(nmath.NewVec3(0, 0, 0), nmath.NewVec3(0, 1, 0))
+			c.Projection = p
+			image := c.Render(raytracer.NewWorld())
+			Expect(image.PixelAt(0, 0).AsVec3().ApproxEq(nmath.NewVec3(0, 0, 0))).To(BeTrue())
+			Expect(image.PixelAt(5, 5).AsVec3().ApproxEq(nmath.NewVec3(0, 0, 0))).To(BeFalse())
+		})
+	})
+
+	Describe("Equirectangular", func() {
+		p := raytracer.Equirectangular{}
+
+		It("should cover every direction", func() {
+			Expect(direction(p, 100, 50, 200, 100).ApproxEq(nmath.NewVec3(0, 0, -1))).To(BeTrue())
+			Expect(direction(p, 150, 50, 200, 100).ApproxEq(nmath.NewVec3(-1, 0, 0))).To(BeTrue())
+			Expect(direction(p, 0, 50, 200, 100).ApproxEq(nmath.NewVec3(0, 0, 1))).To(BeTrue())
+			Expect(direction(p, 100, 0, 200, 100).ApproxEq(nmath.NewVec3(0, 1, 0))).To(BeTrue())
+		})
+	})
+})
@@ -0,0 +1,206 @@
+package raytracer
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"runtime"
+	"sync"
+
+	"github.com/novelalex/soft-raytracer/pkg/geom"
+	"github.com/novelalex/soft-raytracer/pkg/gfx"
+	"github.com/novelalex/soft-raytracer/pkg/nmath"
+)
+
+const DefaultTileSize uint = 16
+
+// Tile is the rectangle of pixels [X0, X1) x [Y0, Y1)
+type Tile struct {
+	X0, Y0 uint
+	X1, Y1 uint
+}
+
+// TileProgress is reported to a ProgressFunc after each tile is finished
+type TileProgress struct {
+	Tile  Tile
+	Done  int
+	Total int
+}
+
+// ProgressFunc is called from the goroutine that called RenderContext,
+// once per finished tile
+type ProgressFunc func(TileProgress)
+
+func (c *Camera) Render(w World) gfx.Canvas {
+	image, _ := c.RenderContext(context.Background(), w, nil)
+	return image
+}
+
+// RenderContext renders the world tile by tile on Workers goroutines.
+// If ctx is cancelled the tiles that were finished are returned along with ctx.Err().
+// A panic while rendering stops the render and is returned as an error, as
+// is a camera transform that can't be inverted.
+func (c *Camera) RenderContext(ctx context.Context, w World, progress ProgressFunc) (gfx.Canvas, error) {
+	if !c.Transform.Invertible() {
+		return gfx.NewCanvas(c.Width, c.Height), fmt.Errorf("raytracer: camera transform can't be inverted")
+	}
+	w.BuildBVH()
+	camera := c.withCachedInverse()
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	tiles := c.tiles()
+	jobs := make(chan int)
+	results := make(chan renderTileResult)
+
+	var wg sync.WaitGroup
+	for range c.workerCount() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// a panic here can't be recovered by the caller, so it cancels the render instead
+			defer func() {
+				if r := recover(); r != nil {
+					cancel(fmt.Errorf("raytracer: render failed: %v", r))
+				}
+			}()
+			camera.renderWorker(ctx, &w, tiles, jobs, results)
+		}()
+	}
+
+	go func() {
+		defer close(jobs)
+		for i := range tiles {
+			select {
+			case jobs <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	films := make([]*gfx.Film, len(tiles))
+	done := 0
+	for result := range results {
+		films[result.Index] = &result.Film
+
+		done++
+		if progress != nil {
+			progress(TileProgress{tiles[result.Index], done, len(tiles)})
+		}
+	}
+
+	// tile films overlap when the filter is wider than a pixel, merging them
+	// in a fixed order keeps the image identical for any number of workers
+	film := gfx.NewFilm(c.Width, c.Height, c.filter())
+	for _, f := range films {
+		if f != nil {
+			film.Merge(*f)
+		}
+	}
+	image := film.Canvas()
+
+	if done < len(tiles) {
+		return image, context.Cause(ctx)
+	}
+	return image, nil
+}
+
+func (c *Camera) workerCount() int {
+	if c.Workers > 0 {
+		return c.Workers
+	}
+	return runtime.NumCPU()
+}
+
+// tiles splits the image into TileSize squares in scanline order,
+// the tiles on the right and bottom edges may be smaller
+func (c *Camera) tiles() []Tile {
+	size := c.TileSize
+	if size == 0 {
+		size = DefaultTileSize
+	}
+
+	tiles := []Tile{}
+	for y := uint(0); y < c.Height; y += size {
+		for x := uint(0); x < c.Width; x += size {
+			tiles = append(tiles, Tile{
+				x, y,
+				min(x+size, c.Width), min(y+size, c.Height),
+			})
+		}
+	}
+	return tiles
+}
+
+type renderTileResult struct {
+	Index int
+	Film  gfx.Film
+}
+
+func (c *Camera) renderWorker(ctx context.Context, w *World, tiles []Tile, jobs <-chan int, results chan<- renderTileResult) {
+	for i := range jobs {
+		film, ok := c.renderTile(ctx, w, tiles[i])
+		if !ok {
+			// keep draining so the dispatcher can't block on a full channel
+			continue
+		}
+		results <- renderTileResult{i, film}
+	}
+}
+
+// tileFilm covers the tile plus the border of pixels its samples can reach
+// through the filter, clipped to the image
+func (c *Camera) tileFilm(tile Tile, filter gfx.Filter) gfx.Film {
+	border := uint(max(0, math.Ceil(filter.Radius()-0.5)))
+	x0 := tile.X0 - min(tile.X0, border)
+	y0 := tile.Y0 - min(tile.Y0, border)
+	x1 := min(tile.X1+border, c.Width)
+	y1 := min(tile.Y1+border, c.Height)
+	return gfx.NewFilmRegion(x0, y0, x1-x0, y1-y0, filter)
+}
+
+// renderTile traces Samples rays through each pixel of the tile,
+// or returns false if ctx was cancelled before it finished
+func (c *Camera) renderTile(ctx context.Context, w *World, tile Tile) (gfx.Film, bool) {
+	film := c.tileFilm(tile, c.filter())
+	sampler := c.sampler()
+	n := c.sampleCount()
+	integrator := c.integrator()
+
+	for y := tile.Y0; y < tile.Y1; y++ {
+		if ctx.Err() != nil {
+			return gfx.Film{}, false
+		}
+		for x := tile.X0; x < tile.X1; x++ {
+			rng := c.pixelRand(x, y)
+			points := sampler.Samples(n, rng)
+			lens := c.lensSamples(sampler, n, rng)
+			for i, s := range points {
+				sx := float64(x) + s.X
+				sy := float64(y) + s.Y
+				var ray geom.Ray
+				var ok bool
+				if lens != nil {
+					ray, ok = c.RayThroughLens(sx, sy, lens[i])
+				} else {
+					ray, ok = c.RayForSample(sx, sy)
+				}
+
+				// positions the projection doesn't cover are black
+				color := nmath.NewColor(0, 0, 0)
+				if ok {
+					color = integrator.Li(w, ray, rng)
+				}
+				film.AddSample(sx, sy, color)
+			}
+		}
+	}
+	return film, true
+}
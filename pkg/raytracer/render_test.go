@@ -0,0 +1,130 @@
+package raytracer_test
+
+import (
+	"context"
+	"math"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/novelalex/soft-raytracer/pkg/gfx"
+	"github.com/novelalex/soft-raytracer/pkg/nmath"
+	"github.com/novelalex/soft-raytracer/pkg/raytracer"
+	"github.com/novelalex/soft-raytracer/pkg/sampling"
+)
+
+// panickingLight stands in for a bug that panics in the middle of a render
+type panickingLight struct{}
+
+func (panickingLight) Samples(nmath.Vec3) []raytracer.LightSample {
+	panic("light is broken")
+}
+
+var _ = Describe("Render", func() {
+	newCamera := func(w, h uint) raytracer.Camera {
+		c := raytracer.NewCamera(w, h, math.Pi/2.0)
+		c.Transform = nmath.NewVec3(0, 0, -5).LookAt(nmath.NewVec3(0, 0, 0), nmath.NewVec3(0, 1, 0))
+		return c
+	}
+
+	Describe("RenderContext", func() {
+		It("should report every tile once", func() {
+			w := raytracer.NewWorld()
+			c := newCamera(21, 11)
+			c.TileSize = 8
+
+			reports := []raytracer.TileProgress{}
+			_, err := c.RenderContext(context.Background(), w, func(p raytracer.TileProgress) {
+				reports = append(reports, p)
+			})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reports).To(HaveLen(6))
+			pixels := uint(0)
+			for i, r := range reports {
+				Expect(r.Done).To(Equal(i + 1))
+				Expect(r.Total).To(Equal(6))
+				pixels += (r.Tile.X1 - r.Tile.X0) * (r.Tile.Y1 - r.Tile.Y0)
+			}
+			Expect(pixels).To(Equal(uint(21 * 11)))
+		})
+
+		It("should render the same image with any number of workers", func() {
+			w := raytracer.NewWorld()
+			c := newCamera(16, 16)
+			c.Workers = 1
+			expected := c.Render(w)
+
+			c.Workers = 7
+			c.TileSize = 3
+			result := c.Render(w)
+
+			for y := range uint(16) {
+				for x := range uint(16) {
+					Expect(result.PixelAt(x, y)).To(Equal(expected.PixelAt(x, y)))
+				}
+			}
+		})
+
+		It("should render the same supersampled image with any number of workers", func() {
+			w := raytracer.NewWorld()
+			c := newCamera(16, 16)
+			c.Samples = 4
+			c.Sampler = sampling.Stratified{}
+			c.Filter = gfx.NewMitchellFilter()
+			c.Seed = 42
+			c.TileSize = 3
+			c.Workers = 1
+			expected := c.Render(w)
+
+			c.Workers = 5
+			result := c.Render(w)
+
+			for y := range uint(16) {
+				for x := range uint(16) {
+					Expect(result.PixelAt(x, y)).To(Equal(expected.PixelAt(x, y)))
+				}
+			}
+		})
+
+		It("should stop when the context is cancelled", func() {
+			w := raytracer.NewWorld()
+			c := newCamera(64, 64)
+			c.TileSize = 4
+			c.Workers = 2
+			ctx, cancel := context.WithCancel(context.Background())
+
+			done := 0
+			_, err := c.RenderContext(ctx, w, func(p raytracer.TileProgress) {
+				done = p.Done
+				if p.Done == 3 {
+					cancel()
+				}
+			})
+
+			Expect(err).To(MatchError(context.Canceled))
+			Expect(done).To(BeNumerically("<", 256))
+		})
+
+		It("should return a panic while rendering as an error", func() {
+			w := raytracer.NewWorld()
+			w.Lights = []raytracer.Light{panickingLight{}}
+			c := newCamera(16, 16)
+			c.TileSize = 4
+			c.Workers = 2
+
+			_, err := c.RenderContext(context.Background(), w, nil)
+
+			Expect(err).To(MatchError(ContainSubstring("light is broken")))
+		})
+
+		It("should return an error for a camera transform that can't be inverted", func() {
+			c := newCamera(4, 4)
+			c.Transform = nmath.NewScaling(0, 1, 1)
+
+			_, err := c.RenderContext(context.Background(), raytracer.NewWorld(), nil)
+
+			Expect(err).To(MatchError(ContainSubstring("can't be inverted")))
+		})
+	})
+})
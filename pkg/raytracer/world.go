@@ -8,8 +8,10 @@ import (
 )
 
 type World struct {
-	Lights  []PointLight
+	Lights  []Light
 	Objects []Object
+
+	accel *bvh
 }
 
 func NewWorld() World {
@@ -26,39 +28,60 @@ func NewWorld() World {
 	o2 := NewObject(&s2, DefaultMaterial())
 
 	return World{
-		[]PointLight{
+		Lights: []Light{
 			NewPointLight(nmath.NewVec3(-10, 10, -10), nmath.NewColor(1, 1, 1)),
 		},
-		[]Object{
+		Objects: []Object{
 			o1, o2,
 		},
 	}
 }
 
-func NewWorldWith(lights []PointLight, objects []Object) World {
+func NewWorldWith(lights []Light, objects []Object) World {
 	w := World{
-		lights,
-		objects,
+		Lights:  lights,
+		Objects: objects,
 	}
 
 	return w
 }
 
+// BuildBVH builds the acceleration structure used by IntersectRay.
+// It has to be called again after Objects is modified, Camera.Render calls it for you.
+func (w *World) BuildBVH() {
+	w.accel = newBVH(w.Objects)
+}
+
 func (w *World) IntersectRay(r geom.Ray) Intersections {
+	return w.intersectRaySegment(r, math.Inf(-1), math.Inf(1))
+}
+
+// intersectRaySegment returns the intersections of every object whose bounds
+// overlap the ray in [tmin, tmax], objects outside of it may still be skipped
+// so callers must filter the returned intersections themselves
+func (w *World) intersectRaySegment(r geom.Ray, tmin, tmax float64) Intersections {
 	intersections := Intersections{}
-	for _, o := range w.Objects {
-		intersections = intersections.Merge(o.IntersectRay(r))
+	if w.accel == nil {
+		for i := range w.Objects {
+			intersections = append(intersections, w.Objects[i].IntersectRay(r)...)
+		}
+		return NewIntersections(intersections)
 	}
-	return intersections
+
+	w.accel.traverse(r, tmin, tmax, func(i int) {
+		intersections = append(intersections, w.Objects[i].IntersectRay(r)...)
+	})
+	return NewIntersections(intersections)
 }
 
 func (w *World) ShadeHit(comps IntersectionPrecomputation, remaining int) nmath.Color {
-	out_color := nmath.NewColor(0, 0, 0)
+	out_color := comps.Object.Material.Emission
 	for _, light := range w.Lights {
-		in_shadow, shadow_strength := w.IsShadowed(comps.OverPoint, light)
+		samples := light.Samples(comps.OverPoint)
+		visibility := w.visibility(comps.OverPoint, samples)
 		object := comps.Object
 		shape := comps.Shape
-		l_color := object.Material.Lighting(shape, light, comps.Point, comps.EyeV, comps.NormalV, in_shadow, shadow_strength)
+		l_color := object.Material.Lighting(shape, samples, comps.Point, comps.EyeV, comps.NormalV, visibility)
 		reflect_color := w.ReflectedColor(comps, remaining)
 		refract_color := w.RefractedColor(comps, remaining)
 
@@ -101,19 +124,28 @@ func (w *World) RefractedColor(comps IntersectionPrecomputation, remaining int)
 		return out_color // BLACK
 	}
 
+	direction, ok := refractDirection(comps)
+	if !ok {
+		return out_color // BLACK
+	}
+	refract_ray := geom.NewRay(comps.UnderPoint, direction)
+	out_color = w.ColorAt(refract_ray, remaining-1).AsVec3().Mult(comps.Object.Material.Transparency).AsColor()
+
+	return out_color
+}
+
+// refractDirection is the direction of the ray refracted at the hit,
+// false if there is total internal reflection
+func refractDirection(comps IntersectionPrecomputation) (nmath.Vec3, bool) {
 	n_ratio := comps.N1 / comps.N2
 	cos_i := comps.EyeV.Dot(comps.NormalV)
 	sin2_t := n_ratio * n_ratio * (1 - cos_i*cos_i)
 	if sin2_t > 1.0 {
-		return out_color // BLACK
+		return nmath.Vec3{}, false
 	}
 
 	cos_t := math.Sqrt(1.0 - sin2_t)
-	direction := comps.NormalV.Mult(n_ratio*cos_i - cos_t).Sub(comps.EyeV.Mult(n_ratio))
-	refract_ray := geom.NewRay(comps.UnderPoint, direction)
-	out_color = w.ColorAt(refract_ray, remaining-1).AsVec3().Mult(comps.Object.Material.Transparency).AsColor()
-
-	return out_color
+	return comps.NormalV.Mult(n_ratio*cos_i - cos_t).Sub(comps.EyeV.Mult(n_ratio)), true
 }
 
 func (w *World) ColorAt(r geom.Ray, remaining int) nmath.Color {
@@ -129,13 +161,41 @@ func (w *World) ColorAt(r geom.Ray, remaining int) nmath.Color {
 	return color
 }
 
-func (w *World) IsShadowed(p nmath.Vec3, l PointLight) (bool, float64) {
-	v := l.Position.Sub(p)
-	dist := v.Mag()
-	dir := v.Normalize()
+// IsShadowed casts a shadow ray to each sample of the light, the strength
+// is the fraction of the light that is blocked. A light without samples
+// casts no shadow.
+func (w *World) IsShadowed(p nmath.Vec3, l Light) (bool, float64) {
+	samples := l.Samples(p)
+	if len(samples) == 0 {
+		return false, 0.0
+	}
 
-	r := geom.NewRay(p, dir)
-	xs := w.IntersectRay(r)
+	visible := 0.0
+	for _, v := range w.visibility(p, samples) {
+		visible += v
+	}
+
+	shadow_strength := 1.0 - visible/float64(len(samples))
+	if shadow_strength > nmath.F64EpsilonLoose {
+		return true, shadow_strength
+	}
+
+	return false, 0.0
+}
+
+func (w *World) visibility(p nmath.Vec3, samples []LightSample) []float64 {
+	visibility := make([]float64, len(samples))
+	for i, sample := range samples {
+		visibility[i] = w.transmission(geom.NewRay(p, sample.Direction), sample.Distance)
+	}
+	return visibility
+}
+
+// transmission is how much light gets through the objects along r up to dist,
+// transparent objects let part of it through. dist is +Inf for a light
+// infinitely far away, which any object along r can shadow.
+func (w *World) transmission(r geom.Ray, dist float64) float64 {
+	xs := w.intersectRaySegment(r, 0, dist)
 
 	transmission := 1.0
 
@@ -149,18 +209,12 @@ func (w *World) IsShadowed(p nmath.Vec3, l PointLight) (bool, float64) {
 
 			// bail if fully opaque
 			if transmission <= nmath.F64EpsilonLoose {
-				return true, 1.0
+				return 0.0
 			}
 		}
 	}
 
-	shadow_strength := 1.0 - transmission
-
-	if shadow_strength > nmath.F64EpsilonLoose {
-		return true, shadow_strength
-	}
-
-	return false, 0.0
+	return transmission
 }
 
 func Schlick(c IntersectionPrecomputation) float64 {
@@ -34,7 +34,8 @@ var _ = Describe("World", func() {
 		It("should return true when an object is between the point and the light", func() {
 			w := raytracer.NewWorld()
 			p := nmath.NewVec3(10, -10, 10)
-			Expect(w.IsShadowed(p, w.Lights[0])).To(BeTrue())
+			in_shadow, _ := w.IsShadowed(p, w.Lights[0])
+			Expect(in_shadow).To(BeTrue())
 		})
 
 		It("should return false when an object is behind the light", func() {
@@ -48,5 +49,38 @@ var _ = Describe("World", func() {
 			p := nmath.NewVec3(-2, 2, -2)
 			Expect(w.IsShadowed(p, w.Lights[0])).To(BeFalse())
 		})
+
+		It("should return false for a light without samples", func() {
+			w := raytracer.NewWorld()
+			light := raytracer.SphereLight{Center: nmath.NewVec3(-10, 10, -10), Radius: 1, Intensity: nmath.NewColor(1, 1, 1)}
+			in_shadow, strength := w.IsShadowed(nmath.NewVec3(10, -10, 10), light)
+			Expect(in_shadow).To(BeFalse())
+			Expect(strength).To(Equal(0.0))
+		})
+	})
+
+	Describe("transparent shadows", func() {
+		floor_shape := geom.NewPlane(nmath.Mat4Identity())
+		floor := raytracer.NewObject(&floor_shape, raytracer.DefaultMaterial())
+		glass_shape := geom.NewPlane(nmath.NewTranslation(0, 5, 0))
+		glass := raytracer.NewObject(&glass_shape, raytracer.DefaultMaterial())
+		glass.Material.Transparency = 0.5
+		light := raytracer.NewPointLight(nmath.NewVec3(0, 10, 0), nmath.NewColor(1, 1, 1))
+		w := raytracer.NewWorldWith([]raytracer.Light{light}, []raytracer.Object{floor, glass})
+		r := geom.NewRay(nmath.NewVec3(0, 1, 0), nmath.NewVec3(0, -1, 0))
+
+		It("should only leave the ambient term scaled by the shadow strength", func() {
+			c := w.ColorAt(r, 0)
+
+			// ambient 0.1 times a shadow strength of 0.5
+			Expect(c.AsVec3().ApproxEq(nmath.NewVec3(0.05, 0.05, 0.05))).To(BeTrue())
+		})
+
+		It("should dim the direct light of the path tracer", func() {
+			c := raytracer.PathTracer{MaxDepth: 1}.Li(&w, r, nil)
+
+			// half of diffuse 0.9 and specular 0.9
+			Expect(c.AsVec3().ApproxEq(nmath.NewVec3(0.9, 0.9, 0.9))).To(BeTrue())
+		})
 	})
 })
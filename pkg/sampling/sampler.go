@@ -0,0 +1,211 @@
+// Package sampling generates the sub-pixel sample positions used for anti-aliasing.
+package sampling
+
+import (
+	"math"
+	"math/bits"
+	"math/rand/v2"
+
+	"github.com/novelalex/soft-raytracer/pkg/nmath"
+)
+
+// Point is a sample position in the unit square [0, 1) x [0, 1)
+type Point struct {
+	X, Y float64
+}
+
+// Sampler places n samples in the unit square of one pixel.
+// Randomized samplers draw from rng so renders are reproducible for a seed.
+type Sampler interface {
+	Samples(n int, rng *rand.Rand) []Point
+}
+
+// gridCell is cell i of n cells of equal area that cover the unit square.
+// They are laid out in rows of a grid as square as possible, and when n
+// doesn't fill the grid the last row is made shorter and its cells wider,
+// so the samples still cover the whole square evenly.
+func gridCell(i, n int) (x0, y0, w, h float64) {
+	cols := int(math.Ceil(math.Sqrt(float64(n))))
+	row, col := i/cols, i%cols
+	in_row := min(cols, n-row*cols)
+
+	w = 1 / float64(in_row)
+	h = float64(in_row) / float64(n)
+	return float64(col) * w, float64(row*cols) / float64(n), w, h
+}
+
+// Regular puts the samples at the centers of the cells of a grid,
+// a single sample is in the middle of the pixel
+type Regular struct{}
+
+func (Regular) Samples(n int, rng *rand.Rand) []Point {
+	points := make([]Point, n)
+	for i := range points {
+		x0, y0, w, h := gridCell(i, n)
+		points[i] = Point{x0 + 0.5*w, y0 + 0.5*h}
+	}
+	return points
+}
+
+// Stratified jitters one sample randomly inside each cell of a grid
+type Stratified struct{}
+
+func (Stratified) Samples(n int, rng *rand.Rand) []Point {
+	points := make([]Point, n)
+	for i := range points {
+		x0, y0, w, h := gridCell(i, n)
+		points[i] = Point{x0 + rng.Float64()*w, y0 + rng.Float64()*h}
+	}
+	return points
+}
+
+// Halton uses the base 2 and 3 Halton sequence, randomly shifted per pixel
+// (Cranley-Patterson rotation) so neighbouring pixels don't share a pattern
+type Halton struct{}
+
+func (Halton) Samples(n int, rng *rand.Rand) []Point {
+	shift_x, shift_y := rng.Float64(), rng.Float64()
+	points := make([]Point, n)
+	for i := range points {
+		points[i] = Point{
+			wrap(radicalInverse(uint64(i), 2) + shift_x),
+			wrap(radicalInverse(uint64(i), 3) + shift_y),
+		}
+	}
+	return points
+}
+
+func radicalInverse(i, base uint64) float64 {
+	inv_base := 1.0 / float64(base)
+	f := inv_base
+	result := 0.0
+	for i > 0 {
+		result += float64(i%base) * f
+		i /= base
+		f *= inv_base
+	}
+	return result
+}
+
+func wrap(x float64) float64 {
+	return x - math.Floor(x)
+}
+
+// Sobol uses the first two dimensions of the Sobol sequence, which form a
+// (0,2) sequence, with a random digital scramble per pixel
+type Sobol struct{}
+
+func (Sobol) Samples(n int, rng *rand.Rand) []Point {
+	scramble_x, scramble_y := rng.Uint32(), rng.Uint32()
+	points := make([]Point, n)
+	for i := range points {
+		points[i] = Point{
+			uint32ToUnit(bits.Reverse32(uint32(i)) ^ scramble_x),
+			uint32ToUnit(sobolSecondDimension(uint32(i)) ^ scramble_y),
+		}
+	}
+	return points
+}
+
+// sobolSecondDimension uses the direction numbers of the primitive polynomial x + 1
+func sobolSecondDimension(i uint32) uint32 {
+	result := uint32(0)
+	v := uint32(1) << 31
+	for ; i > 0; i >>= 1 {
+		if i&1 != 0 {
+			result ^= v
+		}
+		v ^= v >> 1
+	}
+	return result
+}
+
+func uint32ToUnit(v uint32) float64 {
+	return float64(v) / (1 << 32)
+}
+
+// BlueNoise places samples with Mitchell's best candidate algorithm, each new
+// sample is the candidate furthest from the samples already placed. The
+// distance wraps around the pixel so the pattern tiles without clumping.
+type BlueNoise struct {
+	// Candidates per placed sample, 10 if zero
+	Candidates int
+}
+
+func (b BlueNoise) Samples(n int, rng *rand.Rand) []Point {
+	candidates := b.Candidates
+	if candidates <= 0 {
+		candidates = 10
+	}
+
+	points := make([]Point, 0, n)
+	for i := range n {
+		best := Point{}
+		best_dist := -1.0
+		for range candidates * max(i, 1) {
+			c := Point{rng.Float64(), rng.Float64()}
+			d := math.Inf(1)
+			for _, p := range points {
+				d = min(d, toroidalDistance2(c, p))
+			}
+			if d > best_dist {
+				best, best_dist = c, d
+			}
+		}
+		points = append(points, best)
+	}
+	return points
+}
+
+func toroidalDistance2(a, b Point) float64 {
+	dx := math.Abs(a.X - b.X)
+	dy := math.Abs(a.Y - b.Y)
+	dx = min(dx, 1-dx)
+	dy = min(dy, 1-dy)
+	return dx*dx + dy*dy
+}
+
+// ConcentricDisk maps a point in the unit square to the unit disk,
+// keeping stratified samples evenly spread (Shirley and Chiu)
+func ConcentricDisk(p Point) Point {
+	x := 2*p.X - 1
+	y := 2*p.Y - 1
+	if x == 0 && y == 0 {
+		return Point{}
+	}
+
+	var r, theta float64
+	if math.Abs(x) > math.Abs(y) {
+		r = x
+		theta = math.Pi / 4 * (y / x)
+	} else {
+		r = y
+		theta = math.Pi/2 - math.Pi/4*(x/y)
+	}
+	return Point{r * math.Cos(theta), r * math.Sin(theta)}
+}
+
+// Polygon maps a point in the unit square to a regular polygon with the given
+// number of sides inscribed in the unit circle. X picks one of the triangles
+// fanned out from the center, the rest of X and Y place the point inside it.
+func Polygon(p Point, sides int) Point {
+	n := float64(sides)
+	side := min(math.Floor(p.X*n), n-1)
+	u := p.X*n - side
+
+	a0 := 2 * math.Pi * side / n
+	a1 := 2 * math.Pi * (side + 1) / n
+	r := math.Sqrt(u)
+	return Point{
+		r * ((1-p.Y)*math.Cos(a0) + p.Y*math.Cos(a1)),
+		r * ((1-p.Y)*math.Sin(a0) + p.Y*math.Sin(a1)),
+	}
+}
+
+// CosineHemisphere maps a point in the unit square to a direction in the
+// hemisphere around +z, more likely near +z in proportion to the cosine
+func CosineHemisphere(p Point) nmath.Vec3 {
+	d := ConcentricDisk(p)
+	z := math.Sqrt(max(0, 1-d.X*d.X-d.Y*d.Y))
+	return nmath.NewVec3(d.X, d.Y, z)
+}
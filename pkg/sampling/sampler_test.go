@@ -0,0 +1,111 @@
+package sampling_test
+
+import (
+	"math"
+	"math/rand/v2"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/novelalex/soft-raytracer/pkg/sampling"
+)
+
+var _ = Describe("Sampler", func() {
+	samplers := map[string]sampling.Sampler{
+		"regular":    sampling.Regular{},
+		"stratified": sampling.Stratified{},
+		"halton":     sampling.Halton{},
+		"sobol":      sampling.Sobol{},
+		"blue noise": sampling.BlueNoise{},
+	}
+
+	for name, s := range samplers {
+		It("should place "+name+" samples inside the pixel", func() {
+			points := s.Samples(13, rand.New(rand.NewPCG(1, 2)))
+			Expect(points).To(HaveLen(13))
+			for _, p := range points {
+				Expect(p.X).To(BeNumerically(">=", 0))
+				Expect(p.X).To(BeNumerically("<", 1))
+				Expect(p.Y).To(BeNumerically(">=", 0))
+				Expect(p.Y).To(BeNumerically("<", 1))
+			}
+		})
+
+		It("should place the same "+name+" samples for the same seed", func() {
+			a := s.Samples(8, rand.New(rand.NewPCG(7, 3)))
+			b := s.Samples(8, rand.New(rand.NewPCG(7, 3)))
+			Expect(a).To(Equal(b))
+		})
+	}
+
+	It("should put a single regular sample in the middle of the pixel", func() {
+		points := sampling.Regular{}.Samples(1, nil)
+		Expect(points).To(Equal([]sampling.Point{{X: 0.5, Y: 0.5}}))
+	})
+
+	It("should put one stratified sample in each cell", func() {
+		points := sampling.Stratified{}.Samples(16, rand.New(rand.NewPCG(1, 1)))
+		cells := map[[2]int]bool{}
+		for _, p := range points {
+			cells[[2]int{int(p.X * 4), int(p.Y * 4)}] = true
+		}
+		Expect(cells).To(HaveLen(16))
+	})
+
+	It("should center regular samples on the pixel when they don't fill a square grid", func() {
+		for n := 1; n <= 12; n++ {
+			var sum sampling.Point
+			points := sampling.Regular{}.Samples(n, nil)
+			for _, p := range points {
+				sum.X += p.X
+				sum.Y += p.Y
+			}
+			Expect(sum.X/float64(n)).To(BeNumerically("~", 0.5, 1e-12), "n = %d", n)
+			Expect(sum.Y/float64(n)).To(BeNumerically("~", 0.5, 1e-12), "n = %d", n)
+		}
+	})
+
+	It("should average to the middle of the pixel with three regular or stratified samples", func() {
+		for _, s := range []sampling.Sampler{sampling.Regular{}, sampling.Stratified{}} {
+			var mean sampling.Point
+			const pixels = 4000
+			rng := rand.New(rand.NewPCG(3, 3))
+			for range pixels {
+				for _, p := range s.Samples(3, rng) {
+					mean.X += p.X / (3 * pixels)
+					mean.Y += p.Y / (3 * pixels)
+				}
+			}
+			Expect(mean.X).To(BeNumerically("~", 0.5, 0.01))
+			Expect(mean.Y).To(BeNumerically("~", 0.5, 0.01))
+		}
+	})
+
+	It("should put one sobol sample in each row and column of a power of two", func() {
+		points := sampling.Sobol{}.Samples(16, rand.New(rand.NewPCG(5, 5)))
+		rows := map[int]bool{}
+		cols := map[int]bool{}
+		for _, p := range points {
+			cols[int(p.X*16)] = true
+			rows[int(p.Y*16)] = true
+		}
+		Expect(cols).To(HaveLen(16))
+		Expect(rows).To(HaveLen(16))
+	})
+
+	It("should map the unit square onto the unit disk", func() {
+		for _, p := range (sampling.Regular{}).Samples(49, nil) {
+			d := sampling.ConcentricDisk(p)
+			Expect(math.Hypot(d.X, d.Y)).To(BeNumerically("<=", 1+1e-9))
+		}
+		Expect(sampling.ConcentricDisk(sampling.Point{X: 0.5, Y: 0.5})).To(Equal(sampling.Point{}))
+	})
+
+	It("should map the unit square onto a polygon", func() {
+		for _, p := range (sampling.Regular{}).Samples(49, nil) {
+			d := sampling.Polygon(p, 5)
+			Expect(math.Hypot(d.X, d.Y)).To(BeNumerically("<=", 1+1e-9))
+		}
+		Expect(sampling.Polygon(sampling.Point{X: 0.999999999, Y: 1}, 4).X).To(BeNumerically("~", 1, 1e-6))
+	})
+})
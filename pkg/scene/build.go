@@ -0,0 +1,635 @@
+package scene
+
+import (
+	"math"
+	"path/filepath"
+
+	"go.yaml.in/yaml/v3"
+
+	"github.com/novelalex/soft-raytracer/pkg/geom"
+	"github.com/novelalex/soft-raytracer/pkg/nmath"
+	"github.com/novelalex/soft-raytracer/pkg/noise"
+	"github.com/novelalex/soft-raytracer/pkg/raytracer"
+)
+
+func (l *loader) addCamera(entry *yaml.Node) error {
+	if l.has_camera {
+		return errorAt(entry, "scene already has a camera")
+	}
+
+	var width, height uint
+	fov := math.Pi / 3
+	from := nmath.NewVec3(0, 0, -5)
+	to := nmath.NewVec3(0, 0, 0)
+	up := nmath.NewVec3(0, 1, 0)
+	var samples int
+	var aperture, focal_distance float64
+
+	var err error
+	for key, value := range pairs(entry) {
+		switch key.Value {
+		case "add":
+		case "width":
+			width, err = decodeUint(value)
+		case "height":
+			height, err = decodeUint(value)
+		case "field-of-view":
+			fov, err = decodeFloat(value)
+		case "from":
+			from, err = decodeVec3(value)
+		case "to":
+			to, err = decodeVec3(value)
+		case "up":
+			up, err = decodeVec3(value)
+		case "samples":
+			samples, err = decodeInt(value)
+		case "aperture":
+			aperture, err = decodeFloat(value)
+		case "focal-distance":
+			focal_distance, err = decodeFloat(value)
+		default:
+			err = errorAt(key, "unknown key %q for camera", key.Value)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if width == 0 || height == 0 {
+		return errorAt(entry, "camera needs a width and height")
+	}
+
+	// a camera looking at itself or along up has no orientation and can't be inverted
+	if to.Sub(from).Mag() < nmath.F64Epsilon {
+		return errorAt(entry, "camera from and to are the same point")
+	}
+	view_transform := from.LookAt(to, up)
+	if !view_transform.Invertible() {
+		return errorAt(entry, "camera up is along the direction it looks in")
+	}
+
+	c := raytracer.NewCamera(width, height, fov)
+	c.Transform = view_transform
+	c.Samples = samples
+	c.Aperture = aperture
+	c.FocalDistance = focal_distance
+	l.scene.Camera = c
+	l.has_camera = true
+	return nil
+}
+
+func (l *loader) addLight(entry *yaml.Node) error {
+	kind := "point"
+	at := nmath.NewVec3(0, 0, 0)
+	intensity := nmath.NewColor(1, 1, 1)
+	direction := nmath.NewVec3(0, -1, 0)
+	inner, outer := math.Pi/8, math.Pi/6
+	uvec := nmath.NewVec3(1, 0, 0)
+	vvec := nmath.NewVec3(0, 0, 1)
+	usteps, vsteps := 1, 1
+	radius := 1.0
+	samples := 1
+
+	var err error
+	for key, value := range pairs(entry) {
+		switch key.Value {
+		case "add":
+		case "type":
+			kind, err = decodeString(value)
+		case "at":
+			at, err = decodeVec3(value)
+		case "intensity":
+			intensity, err = decodeColor(value)
+		case "direction":
+			direction, err = decodeVec3(value)
+		case "inner-angle":
+			inner, err = decodeFloat(value)
+		case "outer-angle":
+			outer, err = decodeFloat(value)
+		case "uvec":
+			uvec, err = decodeVec3(value)
+		case "vvec":
+			vvec, err = decodeVec3(value)
+		case "usteps":
+			usteps, err = decodeInt(value)
+		case "vsteps":
+			vsteps, err = decodeInt(value)
+		case "radius":
+			radius, err = decodeFloat(value)
+		case "samples":
+			samples, err = decodeInt(value)
+		default:
+			err = errorAt(key, "unknown key %q for light", key.Value)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	var light raytracer.Light
+	switch kind {
+	case "point":
+		light = raytracer.NewPointLight(at, intensity)
+	case "directional":
+		light = raytracer.NewDirectionalLight(direction, intensity)
+	case "spot":
+		light = raytracer.NewSpotLight(at, direction, inner, outer, intensity)
+	case "rect":
+		light = raytracer.NewRectLight(at, uvec, vvec, usteps, vsteps, intensity)
+	case "sphere":
+		light = raytracer.NewSphereLight(at, radius, samples, intensity)
+	default:
+		return errorAt(mappingValue(entry, "type"), "unknown light type %q", kind)
+	}
+	l.scene.World.Lights = append(l.scene.World.Lights, light)
+	return nil
+}
+
+func (l *loader) addObject(entry *yaml.Node, kind string) error {
+	material := raytracer.DefaultMaterial()
+	transform := nmath.Mat4Identity()
+	minimum, maximum := math.Inf(-1), math.Inf(1)
+	closed := false
+
+	var err error
+	for key, value := range pairs(entry) {
+		switch key.Value {
+		case "add":
+		case "material":
+			material, err = l.material(value)
+		case "transform":
+			transform, err = l.transform(value)
+		case "min", "max", "closed":
+			if kind != "cylinder" && kind != "cone" {
+				return errorAt(key, "only cylinders and cones have %q", key.Value)
+			}
+			switch key.Value {
+			case "min":
+				minimum, err = decodeFloat(value)
+			case "max":
+				maximum, err = decodeFloat(value)
+			case "closed":
+				closed, err = decodeBool(value)
+			}
+		default:
+			err = errorAt(key, "unknown key %q for %s", key.Value, kind)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	var shape geom.Shape
+	switch kind {
+	case "sphere":
+		s := geom.DefaultSphere()
+		shape = &s
+	case "plane":
+		p := geom.DefaultPlane()
+		shape = &p
+	case "cube":
+		c := geom.DefaultCube()
+		shape = &c
+	case "cylinder":
+		c := geom.NewTruncatedCylinder(minimum, maximum, closed)
+		shape = &c
+	case "cone":
+		c := geom.NewTruncatedCone(minimum, maximum, closed)
+		shape = &c
+	}
+	shape.SetTransform(transform)
+
+	l.scene.World.Objects = append(l.scene.World.Objects, raytracer.NewObject(shape, material))
+	return nil
+}
+
+func (l *loader) material(node *yaml.Node) (raytracer.Material, error) {
+	m := raytracer.DefaultMaterial()
+	node, err := l.resolve(node)
+	if err != nil {
+		return m, err
+	}
+	if node.Kind != yaml.MappingNode {
+		return m, errorAt(node, "material must be a mapping or the name of one")
+	}
+
+	// setting metallic or roughness switches the material to PBR shading
+	pbr := func() *raytracer.PBR {
+		if m.PBR == nil {
+			m.PBR = &raytracer.PBR{Roughness: 0.5}
+		}
+		return m.PBR
+	}
+
+	for key, value := range pairs(node) {
+		switch key.Value {
+		case "color":
+			m.Color, err = decodeColor(value)
+		case "ambient":
+			m.Ambient, err = decodeFloat(value)
+		case "diffuse":
+			m.Diffuse, err = decodeFloat(value)
+		case "specular":
+			m.Specular, err = decodeFloat(value)
+		case "shininess":
+			m.Shininess, err = decodeFloat(value)
+		case "reflective":
+			m.Reflective, err = decodeFloat(value)
+		case "transparency":
+			m.Transparency, err = decodeFloat(value)
+		case "refractive-index":
+			m.IOR, err = decodeFloat(value)
+		case "emission":
+			m.Emission, err = decodeColor(value)
+		case "pattern":
+			m.Pattern, err = l.pattern(value)
+		case "bump":
+			m.Bump, err = l.bump(value)
+		case "metallic":
+			pbr().Metallic, err = decodeFloat(value)
+		case "roughness":
+			pbr().Roughness, err = decodeFloat(value)
+		default:
+			err = errorAt(key, "unknown key %q for material", key.Value)
+		}
+		if err != nil {
+			return m, err
+		}
+	}
+	return m, nil
+}
+
+func (l *loader) pattern(node *yaml.Node) (geom.Pattern, error) {
+	node, err := l.resolve(node)
+	if err != nil {
+		return nil, err
+	}
+	if node.Kind != yaml.MappingNode {
+		return nil, errorAt(node, "pattern must be a mapping or the name of one")
+	}
+
+	kind := ""
+	var inputs []geom.Pattern
+	var mask geom.Pattern
+	mode := geom.BlendAverage
+	transform := nmath.Mat4Identity()
+	var file, mapping *yaml.Node
+	filter := geom.BilinearFilter
+	wrap := geom.WrapRepeat
+	var seed uint64
+	var turbulence *float64
+	var inner geom.Pattern
+	scale := 0.2
+	for key, value := range pairs(node) {
+		switch key.Value {
+		case "type":
+			kind, err = decodeString(value)
+		case "colors":
+			inputs, err = l.patternInputs(value)
+		case "mask":
+			mask, err = l.pattern(value)
+		case "mode":
+			mode, err = decodeChoice(value, map[string]geom.BlendMode{
+				"average":  geom.BlendAverage,
+				"multiply": geom.BlendMultiply,
+			})
+		case "transform":
+			transform, err = l.transform(value)
+		case "file":
+			file = value
+		case "mapping":
+			mapping = value
+		case "filter":
+			filter, err = decodeChoice(value, map[string]geom.TextureFilter{
+				"nearest":  geom.NearestFilter,
+				"bilinear": geom.BilinearFilter,
+			})
+		case "wrap":
+			wrap, err = decodeChoice(value, map[string]geom.WrapMode{
+				"repeat": geom.WrapRepeat,
+				"clamp":  geom.WrapClamp,
+				"mirror": geom.WrapMirror,
+			})
+		case "seed":
+			seed, err = decodeScalar[uint64](value, "a positive integer")
+		case "turbulence":
+			var t float64
+			t, err = decodeFloat(value)
+			turbulence = &t
+		case "scale":
+			scale, err = decodeFloat(value)
+		case "pattern":
+			inner, err = l.pattern(value)
+		default:
+			err = errorAt(key, "unknown key %q for pattern", key.Value)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if kind == "image" {
+		pattern, err := l.imagePattern(node, file, mapping)
+		if err != nil {
+			return nil, err
+		}
+		pattern.Texture.Filter = filter
+		pattern.Texture.Wrap = wrap
+		pattern.SetTransform(transform)
+		return pattern, nil
+	}
+	if kind == "perturb" {
+		if inner == nil {
+			return nil, errorAt(node, "perturb pattern needs a pattern")
+		}
+		p := geom.NewPerturbPattern(inner, noise.Fractal{Source: noise.NewPerlin(seed)}, scale)
+		p.SetTransform(transform)
+		return &p, nil
+	}
+	if kind == "blend" {
+		if len(inputs) == 0 {
+			return nil, errorAt(node, "blend pattern needs colors")
+		}
+		p := geom.NewBlendPattern(mode, inputs...)
+		p.SetTransform(transform)
+		return &p, nil
+	}
+	if len(inputs) != 2 {
+		return nil, errorAt(node, "pattern needs 2 colors")
+	}
+	a, b := inputs[0], inputs[1]
+
+	var pattern geom.Pattern
+	switch kind {
+	case "stripes":
+		pattern = &geom.StripePattern{A: a, B: b}
+	case "gradient":
+		pattern = &geom.GradientPattern{A: a, B: b}
+	case "rings":
+		pattern = &geom.RingPattern{A: a, B: b}
+	case "checkers":
+		pattern = &geom.CheckerPattern{A: a, B: b}
+	case "mask":
+		if mask == nil {
+			return nil, errorAt(node, "mask pattern needs a mask")
+		}
+		pattern = &geom.MaskPattern{A: a, B: b, Mask: mask}
+	case "wood":
+		p := geom.NewWoodPattern(nmath.Color{}, nmath.Color{}, seed)
+		p.A, p.B = a, b
+		if turbulence != nil {
+			p.Turbulence = *turbulence
+		}
+		pattern = &p
+	case "marble":
+		p := geom.NewMarblePattern(nmath.Color{}, nmath.Color{}, seed)
+		p.A, p.B = a, b
+		if turbulence != nil {
+			p.Turbulence = *turbulence
+		}
+		pattern = &p
+	default:
+		return nil, errorAt(node, "unknown pattern type %q", kind)
+	}
+	pattern.SetTransform(transform)
+	return pattern, nil
+}
+
+// patternInputs are the colors a pattern switches between, each one is
+// either a color or a pattern of its own
+func (l *loader) patternInputs(node *yaml.Node) ([]geom.Pattern, error) {
+	if node.Kind != yaml.SequenceNode {
+		return nil, errorAt(node, "expected a list of colors or patterns")
+	}
+	inputs := make([]geom.Pattern, len(node.Content))
+	for i, item := range node.Content {
+		item, err := l.resolve(item)
+		if err != nil {
+			return nil, err
+		}
+		if item.Kind == yaml.SequenceNode {
+			c, err := decodeColor(item)
+			if err != nil {
+				return nil, err
+			}
+			inputs[i] = geom.NewSolidPattern(c)
+		} else if inputs[i], err = l.pattern(item); err != nil {
+			return nil, err
+		}
+	}
+	return inputs, nil
+}
+
+// imagePattern loads the texture of an image pattern
+func (l *loader) imagePattern(node, file, mapping *yaml.Node) (*geom.ImagePattern, error) {
+	if file == nil {
+		return nil, errorAt(node, "image pattern needs a file")
+	}
+	texture, err := l.texture(file, geom.LoadTexture)
+	if err != nil {
+		return nil, err
+	}
+	uv, err := decodeMapping(mapping)
+	if err != nil {
+		return nil, err
+	}
+
+	texture.Cells = mappingCells(mapping)
+
+	p := geom.NewImagePattern(texture, uv)
+	return &p, nil
+}
+
+// texture loads the file named by node with load,
+// a relative file is found next to the scene file
+func (l *loader) texture(node *yaml.Node, load func(string) (geom.Texture, error)) (geom.Texture, error) {
+	path, err := decodeString(node)
+	if err != nil {
+		return geom.Texture{}, err
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(l.dir, path)
+	}
+	texture, err := load(path)
+	if err != nil {
+		return geom.Texture{}, errorAt(node, "%v", err)
+	}
+	return texture, nil
+}
+
+// mappingCells keeps filtering inside the faces of a cube mapped texture
+func mappingCells(mapping *yaml.Node) [2]int {
+	if mapping != nil && mapping.Value == "cube" {
+		return [2]int{4, 3}
+	}
+	return [2]int{}
+}
+
+// decodeMapping is planar when node is nil
+func decodeMapping(node *yaml.Node) (geom.UVMapping, error) {
+	if node == nil {
+		return geom.PlanarMapping, nil
+	}
+	return decodeChoice(node, map[string]geom.UVMapping{
+		"planar":      geom.PlanarMapping,
+		"spherical":   geom.SphericalMapping,
+		"cylindrical": geom.CylindricalMapping,
+		"cube":        geom.CubeMapping,
+	})
+}
+
+func (l *loader) bump(node *yaml.Node) (geom.Bump, error) {
+	node, err := l.resolve(node)
+	if err != nil {
+		return nil, err
+	}
+	if node.Kind != yaml.MappingNode {
+		return nil, errorAt(node, "bump must be a mapping or the name of one")
+	}
+
+	kind := ""
+	transform := nmath.Mat4Identity()
+	var file, mapping *yaml.Node
+	filter := geom.BilinearFilter
+	wrap := geom.WrapRepeat
+	var seed uint64
+	scale := 1.0
+	strength := 1.0
+	for key, value := range pairs(node) {
+		switch key.Value {
+		case "type":
+			kind, err = decodeString(value)
+		case "transform":
+			transform, err = l.transform(value)
+		case "file":
+			file = value
+		case "mapping":
+			mapping = value
+		case "filter":
+			filter, err = decodeChoice(value, map[string]geom.TextureFilter{
+				"nearest":  geom.NearestFilter,
+				"bilinear": geom.BilinearFilter,
+			})
+		case "wrap":
+			wrap, err = decodeChoice(value, map[string]geom.WrapMode{
+				"repeat": geom.WrapRepeat,
+				"clamp":  geom.WrapClamp,
+				"mirror": geom.WrapMirror,
+			})
+		case "seed":
+			seed, err = decodeScalar[uint64](value, "a positive integer")
+		case "scale":
+			scale, err = decodeFloat(value)
+		case "strength":
+			strength, err = decodeFloat(value)
+		default:
+			err = errorAt(key, "unknown key %q for bump", key.Value)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var bump geom.Bump
+	switch kind {
+	case "noise":
+		height := noise.Fractal{Source: noise.NewPerlin(seed)}
+		b := geom.NewHeightBump(height.At, scale)
+		bump = &b
+	case "normal-map":
+		if file == nil {
+			return nil, errorAt(node, "normal-map bump needs a file")
+		}
+		texture, err := l.texture(file, geom.LoadDataTexture)
+		if err != nil {
+			return nil, err
+		}
+		texture.Filter = filter
+		texture.Wrap = wrap
+		uv, err := decodeMapping(mapping)
+		if err != nil {
+			return nil, err
+		}
+		texture.Cells = mappingCells(mapping)
+		m := geom.NewNormalMap(texture, uv)
+		m.Strength = strength
+		bump = &m
+	default:
+		return nil, errorAt(node, "unknown bump type %q", kind)
+	}
+	bump.SetTransform(transform)
+	return bump, nil
+}
+
+// transform composes a list of transforms, each applied after the ones
+// before it. Items are either [operation, args...] or the name of a
+// defined list of transforms.
+func (l *loader) transform(node *yaml.Node) (nmath.Mat4, error) {
+	result := nmath.Mat4Identity()
+	node, err := l.resolve(node)
+	if err != nil {
+		return result, err
+	}
+	if node.Kind != yaml.SequenceNode {
+		return result, errorAt(node, "transform must be a list or the name of one")
+	}
+
+	for _, item := range node.Content {
+		var m nmath.Mat4
+		if item.Kind == yaml.ScalarNode {
+			m, err = l.transform(item)
+		} else {
+			m, err = transformStep(item)
+		}
+		if err != nil {
+			return result, err
+		}
+		result = m.Mult(result)
+	}
+	if !result.Invertible() {
+		return result, errorAt(node, "transform can't be inverted")
+	}
+	return result, nil
+}
+
+func transformStep(node *yaml.Node) (nmath.Mat4, error) {
+	if node.Kind != yaml.SequenceNode || len(node.Content) == 0 {
+		return nmath.Mat4{}, errorAt(node, "transform must be [operation, args...]")
+	}
+
+	op := node.Content[0].Value
+	args := make([]float64, len(node.Content)-1)
+	for i, arg := range node.Content[1:] {
+		v, err := decodeFloat(arg)
+		if err != nil {
+			return nmath.Mat4{}, err
+		}
+		args[i] = v
+	}
+
+	want := map[string]int{
+		"translate": 3, "scale": 3, "shear": 6,
+		"rotate-x": 1, "rotate-y": 1, "rotate-z": 1,
+	}
+	n, ok := want[op]
+	if !ok {
+		return nmath.Mat4{}, errorAt(node.Content[0], "unknown transform %q", op)
+	}
+	if len(args) != n {
+		return nmath.Mat4{}, errorAt(node, "%s needs %d numbers", op, n)
+	}
+
+	switch op {
+	case "translate":
+		return nmath.NewTranslation(args[0], args[1], args[2]), nil
+	case "scale":
+		return nmath.NewScaling(args[0], args[1], args[2]), nil
+	case "shear":
+		return nmath.NewShearing(args[0], args[1], args[2], args[3], args[4], args[5]), nil
+	case "rotate-x":
+		return nmath.NewRotationX(args[0]), nil
+	case "rotate-y":
+		return nmath.NewRotationY(args[0]), nil
+	default:
+		return nmath.NewRotationZ(args[0]), nil
+	}
+}
@@ -0,0 +1,265 @@
+// Package scene loads scenes described in YAML.
+//
+// A scene file is a list of entries. Each entry either adds something to the
+// scene or defines a reusable value:
+//
+//	# scene.yaml
+//	- add: camera
+//	  width: 100
+//	  height: 50
+//	  field-of-view: 1.047
+//	  from: [0, 1.5, -5]
+//	  to: [0, 1, 0]
+//	  up: [0, 1, 0]
+//
+//	- add: light
+//	  at: [-10, 10, -10]
+//	  intensity: [1, 1, 1]
+//
+//	- define: shiny
+//	  value:
+//	    specular: 1
+//	    shininess: 300
+//
+//	- define: red-shiny
+//	  extend: shiny
+//	  value:
+//	    color: [1, 0, 0]
+//
+//	- add: sphere
+//	  material: red-shiny
+//	  transform:
+//	    - [scale, 0.5, 0.5, 0.5]
+//	    - [translate, 0, 1, 0]
+//
+// Transforms are applied in the order they are listed. Materials and
+// transforms can name a definition instead of being written out, and a
+// definition can extend another one, overriding the keys of a material or
+// appending to a list of transforms. Image patterns and normal maps name their
+// file relative to the scene file. A material with metallic or roughness is
+// shaded with the PBR model instead of Phong.
+package scene
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+
+	"go.yaml.in/yaml/v3"
+
+	"github.com/novelalex/soft-raytracer/pkg/raytracer"
+)
+
+type Scene struct {
+	Camera raytracer.Camera
+	World  raytracer.World
+}
+
+// Error is returned for scene files that can't be loaded,
+// Line and Column point at the YAML that caused it
+type Error struct {
+	Line   int
+	Column int
+	Msg    string
+}
+
+func (e *Error) Error() string {
+	if e.Column == 0 {
+		return fmt.Sprintf("scene: line %d: %s", e.Line, e.Msg)
+	}
+	return fmt.Sprintf("scene: line %d, column %d: %s", e.Line, e.Column, e.Msg)
+}
+
+func errorAt(node *yaml.Node, format string, args ...any) error {
+	return &Error{node.Line, node.Column, fmt.Sprintf(format, args...)}
+}
+
+func LoadFile(path string) (*Scene, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return loadFrom(f, filepath.Dir(path))
+}
+
+var yamlErrorLine = regexp.MustCompile(`^yaml: line (\d+): (.*)$`)
+
+// Load reads a scene, files it refers to are relative to the working directory
+func Load(r io.Reader) (*Scene, error) {
+	return loadFrom(r, "")
+}
+
+func loadFrom(r io.Reader, dir string) (*Scene, error) {
+	var document yaml.Node
+	if err := yaml.NewDecoder(r).Decode(&document); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, &Error{1, 0, "scene file is empty"}
+		}
+		if m := yamlErrorLine.FindStringSubmatch(err.Error()); m != nil {
+			line, _ := strconv.Atoi(m[1])
+			return nil, &Error{line, 0, m[2]}
+		}
+		return nil, err
+	}
+	if len(document.Content) == 0 {
+		return nil, &Error{1, 0, "scene file is empty"}
+	}
+
+	l := loader{
+		dir:     dir,
+		defines: map[string]*yaml.Node{},
+		scene: &Scene{
+			World: raytracer.NewWorldWith([]raytracer.Light{}, []raytracer.Object{}),
+		},
+	}
+	if err := l.load(document.Content[0]); err != nil {
+		return nil, err
+	}
+	if !l.has_camera {
+		return nil, errorAt(&document, "scene has no camera")
+	}
+	return l.scene, nil
+}
+
+type loader struct {
+	dir        string
+	defines    map[string]*yaml.Node
+	scene      *Scene
+	has_camera bool
+}
+
+func (l *loader) load(root *yaml.Node) error {
+	if root.Kind != yaml.SequenceNode {
+		return errorAt(root, "scene must be a list of entries")
+	}
+
+	for _, entry := range root.Content {
+		if entry.Kind != yaml.MappingNode {
+			return errorAt(entry, "entry must be a mapping")
+		}
+		if add := mappingValue(entry, "add"); add != nil {
+			if err := l.add(entry, add); err != nil {
+				return err
+			}
+		} else if define := mappingValue(entry, "define"); define != nil {
+			if err := l.define(entry, define); err != nil {
+				return err
+			}
+		} else {
+			return errorAt(entry, "entry needs either add or define")
+		}
+	}
+	return nil
+}
+
+func (l *loader) define(entry, name *yaml.Node) error {
+	if name.Kind != yaml.ScalarNode {
+		return errorAt(name, "definition name must be a string")
+	}
+
+	var value, extend *yaml.Node
+	for key, v := range pairs(entry) {
+		switch key.Value {
+		case "define":
+		case "value":
+			value = v
+		case "extend":
+			extend = v
+		default:
+			return errorAt(key, "unknown key %q in definition", key.Value)
+		}
+	}
+	if value == nil {
+		return errorAt(entry, "definition %q has no value", name.Value)
+	}
+
+	if extend != nil {
+		parent, ok := l.defines[extend.Value]
+		if !ok {
+			return errorAt(extend, "%q is not defined", extend.Value)
+		}
+		merged, err := mergeNodes(parent, value)
+		if err != nil {
+			return err
+		}
+		value = merged
+	}
+
+	l.defines[name.Value] = value
+	return nil
+}
+
+// mergeNodes is parent extended by child, the keys of child replace those
+// of parent in mappings and lists are joined
+func mergeNodes(parent, child *yaml.Node) (*yaml.Node, error) {
+	if parent.Kind != child.Kind {
+		return nil, errorAt(child, "can't extend a definition of a different kind")
+	}
+
+	merged := *child
+	switch child.Kind {
+	case yaml.MappingNode:
+		merged.Content = nil
+		for key, value := range pairs(parent) {
+			if mappingValue(child, key.Value) == nil {
+				merged.Content = append(merged.Content, key, value)
+			}
+		}
+		merged.Content = append(merged.Content, child.Content...)
+	case yaml.SequenceNode:
+		merged.Content = append(append([]*yaml.Node{}, parent.Content...), child.Content...)
+	default:
+		return nil, errorAt(child, "only mappings and lists can be extended")
+	}
+	return &merged, nil
+}
+
+// resolve replaces the name of a definition with its value
+func (l *loader) resolve(node *yaml.Node) (*yaml.Node, error) {
+	if node.Kind != yaml.ScalarNode || node.Tag != "!!str" {
+		return node, nil
+	}
+	value, ok := l.defines[node.Value]
+	if !ok {
+		return nil, errorAt(node, "%q is not defined", node.Value)
+	}
+	return value, nil
+}
+
+func (l *loader) add(entry, kind *yaml.Node) error {
+	switch kind.Value {
+	case "camera":
+		return l.addCamera(entry)
+	case "light":
+		return l.addLight(entry)
+	case "sphere", "plane", "cube", "cylinder", "cone":
+		return l.addObject(entry, kind.Value)
+	}
+	return errorAt(kind, "can't add %q", kind.Value)
+}
+
+// pairs iterates over the keys and values of a mapping node
+func pairs(node *yaml.Node) iter.Seq2[*yaml.Node, *yaml.Node] {
+	return func(yield func(key, value *yaml.Node) bool) {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if !yield(node.Content[i], node.Content[i+1]) {
+				return
+			}
+		}
+	}
+}
+
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	for k, v := range pairs(node) {
+		if k.Value == key {
+			return v
+		}
+	}
+	return nil
+}
@@ -0,0 +1,13 @@
+package scene_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestScene(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Scene Suite")
+}
@@ -0,0 +1,320 @@
+package scene_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/novelalex/soft-raytracer/pkg/geom"
+	"github.com/novelalex/soft-raytracer/pkg/gfx"
+	"github.com/novelalex/soft-raytracer/pkg/nmath"
+	"github.com/novelalex/soft-raytracer/pkg/raytracer"
+	"github.com/novelalex/soft-raytracer/pkg/scene"
+)
+
+const example = `
+- add: camera
+  width: 100
+  height: 50
+  field-of-view: 0.785
+  from: [0, 1.5, -5]
+  to: [0, 1, 0]
+  up: [0, 1, 0]
+
+- add: light
+  at: [-10, 10, -10]
+  intensity: [1, 1, 1]
+
+- add: light
+  type: rect
+  at: [0, 5, 0]
+  uvec: [2, 0, 0]
+  vvec: [0, 0, 2]
+  usteps: 2
+  vsteps: 3
+  intensity: [0.5, 0.5, 0.5]
+
+- define: white
+  value:
+    color: [1, 1, 1]
+    diffuse: 0.7
+    ambient: 0.1
+
+- define: blue
+  extend: white
+  value:
+    color: [0, 0, 1]
+
+- define: lift
+  value:
+    - [scale, 0.5, 0.5, 0.5]
+    - [translate, 0, 1, 0]
+
+- add: sphere
+  material: blue
+  transform:
+    - lift
+    - [translate, 1, 0, 0]
+
+- add: plane
+  material:
+    pattern:
+      type: checkers
+      colors:
+        - [1, 1, 1]
+        - [0, 0, 0]
+      transform:
+        - [scale, 0.25, 0.25, 0.25]
+
+- add: cylinder
+  min: 0
+  max: 2
+  closed: true
+`
+
+var _ = Describe("Load", func() {
+	load := func(text string) (*scene.Scene, error) {
+		return scene.Load(strings.NewReader(text))
+	}
+
+	It("should load the camera", func() {
+		s, err := load(example)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(s.Camera.Width).To(Equal(uint(100)))
+		Expect(s.Camera.Height).To(Equal(uint(50)))
+		Expect(s.Camera.FOV).To(Equal(0.785))
+		expected := nmath.NewVec3(0, 1.5, -5).LookAt(nmath.NewVec3(0, 1, 0), nmath.NewVec3(0, 1, 0))
+		Expect(s.Camera.Transform.ApproxEq(expected)).To(BeTrue())
+	})
+
+	It("should load the lights", func() {
+		s, err := load(example)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(s.World.Lights).To(HaveLen(2))
+		Expect(s.World.Lights[0]).To(Equal(raytracer.NewPointLight(nmath.NewVec3(-10, 10, -10), nmath.NewColor(1, 1, 1))))
+		rect := s.World.Lights[1].(raytracer.RectLight)
+		Expect(rect.USteps * rect.VSteps).To(Equal(6))
+	})
+
+	It("should extend definitions", func() {
+		s, err := load(example)
+		Expect(err).NotTo(HaveOccurred())
+
+		m := s.World.Objects[0].Material
+		Expect(m.Color).To(Equal(nmath.NewColor(0, 0, 1)))
+		Expect(m.Diffuse).To(Equal(0.7))
+		Expect(m.Specular).To(Equal(raytracer.DefaultMaterial().Specular))
+	})
+
+	It("should apply transforms in order", func() {
+		s, err := load(example)
+		Expect(err).NotTo(HaveOccurred())
+
+		expected := nmath.NewTranslation(1, 0, 0).
+			Mult(nmath.NewTranslation(0, 1, 0)).
+			Mult(nmath.NewScaling(0.5, 0.5, 0.5))
+		Expect(s.World.Objects[0].Shape.Transform().Matrix().ApproxEq(expected)).To(BeTrue())
+	})
+
+	It("should load patterns and shapes", func() {
+		s, err := load(example)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(s.World.Objects).To(HaveLen(3))
+		Expect(s.World.Objects[1].Material.Pattern).To(BeAssignableToTypeOf(&geom.CheckerPattern{}))
+		Expect(s.World.Objects[1].Material.Pattern.Transform().Matrix().ApproxEq(nmath.NewScaling(0.25, 0.25, 0.25))).To(BeTrue())
+
+		cyl := s.World.Objects[2].Shape.(*geom.Cylinder)
+		Expect(cyl.Minimum).To(Equal(0.0))
+		Expect(cyl.Maximum).To(Equal(2.0))
+		Expect(cyl.Closed).To(BeTrue())
+	})
+
+	It("should load noise patterns", func() {
+		s, err := load(example + `
+- add: cube
+  material:
+    pattern:
+      type: perturb
+      scale: 0.5
+      seed: 3
+      pattern:
+        type: marble
+        colors: [[1, 1, 1], [0, 0, 0]]
+        turbulence: 2
+`)
+		Expect(err).NotTo(HaveOccurred())
+
+		p := s.World.Objects[3].Material.Pattern.(*geom.PerturbPattern)
+		Expect(p.Scale).To(Equal(0.5))
+		marble := p.Pattern.(*geom.MarblePattern)
+		Expect(marble.Turbulence).To(Equal(2.0))
+	})
+
+	It("should load nested, blended and masked patterns", func() {
+		s, err := load(example + `
+- define: red
+  value: [1, 0, 0]
+
+- define: red-stripes
+  value:
+    type: stripes
+    colors: [red, [1, 1, 1]]
+    transform:
+      - [scale, 0.5, 0.5, 0.5]
+
+- add: cube
+  material:
+    pattern:
+      type: mask
+      colors:
+        - type: checkers
+          colors: [red-stripes, [0, 0, 0]]
+        - type: blend
+          mode: multiply
+          colors: [red-stripes, [0.5, 0.5, 0.5]]
+      mask:
+        type: gradient
+        colors: [[0, 0, 0], [1, 1, 1]]
+`)
+		Expect(err).NotTo(HaveOccurred())
+
+		mask := s.World.Objects[3].Material.Pattern.(*geom.MaskPattern)
+		checkers := mask.A.(*geom.CheckerPattern)
+		stripes := checkers.A.(*geom.StripePattern)
+		Expect(stripes.A).To(Equal(geom.NewSolidPattern(nmath.NewColor(1, 0, 0))))
+		Expect(stripes.Transform().Matrix().ApproxEq(nmath.NewScaling(0.5, 0.5, 0.5))).To(BeTrue())
+		Expect(checkers.B).To(Equal(geom.NewSolidPattern(nmath.NewColor(0, 0, 0))))
+
+		blend := mask.B.(*geom.BlendPattern)
+		Expect(blend.Mode).To(Equal(geom.BlendMultiply))
+		Expect(blend.Patterns).To(HaveLen(2))
+		Expect(mask.Mask).To(BeAssignableToTypeOf(&geom.GradientPattern{}))
+	})
+
+	It("should load image patterns next to the scene file", func() {
+		dir := GinkgoT().TempDir()
+		image := gfx.NewCanvas(2, 2)
+		Expect(os.WriteFile(filepath.Join(dir, "label.ppm"), image.AsP6PPM(), 0644)).To(Succeed())
+		path := filepath.Join(dir, "scene.yaml")
+		Expect(os.WriteFile(path, []byte(example+`
+- add: sphere
+  material:
+    pattern:
+      type: image
+      file: label.ppm
+      mapping: spherical
+      filter: nearest
+      wrap: mirror
+
+- add: cube
+  material:
+    pattern:
+      type: image
+      file: label.ppm
+      mapping: cube
+`), 0644)).To(Succeed())
+
+		s, err := scene.LoadFile(path)
+		Expect(err).NotTo(HaveOccurred())
+		p := s.World.Objects[3].Material.Pattern.(*geom.ImagePattern)
+		Expect(p.Texture.Image.Width()).To(Equal(uint(2)))
+		Expect(p.Texture.Filter).To(Equal(geom.NearestFilter))
+		Expect(p.Texture.Wrap).To(Equal(geom.WrapMirror))
+		Expect(p.Texture.Cells).To(Equal([2]int{}))
+		Expect(s.World.Objects[4].Material.Pattern.(*geom.ImagePattern).Texture.Cells).To(Equal([2]int{4, 3}))
+	})
+
+	It("should switch materials with metallic or roughness to PBR", func() {
+		s, err := load(example + `
+- add: sphere
+  material:
+    color: [1, 0.8, 0.3]
+    metallic: 1
+
+- add: sphere
+  material:
+    roughness: 0.2
+`)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(s.World.Objects[0].Material.PBR).To(BeNil())
+		Expect(*s.World.Objects[3].Material.PBR).To(Equal(raytracer.PBR{Metallic: 1, Roughness: 0.5}))
+		Expect(*s.World.Objects[4].Material.PBR).To(Equal(raytracer.PBR{Metallic: 0, Roughness: 0.2}))
+	})
+
+	It("should load bumps and normal maps", func() {
+		dir := GinkgoT().TempDir()
+		image := gfx.NewCanvas(1, 1)
+		image.WritePixel(0, 0, nmath.NewColor(0.5, 0.5, 1))
+		Expect(os.WriteFile(filepath.Join(dir, "normals.ppm"), image.AsP6PPM(), 0644)).To(Succeed())
+		path := filepath.Join(dir, "scene.yaml")
+		Expect(os.WriteFile(path, []byte(example+`
+- add: sphere
+  material:
+    bump:
+      type: noise
+      scale: 0.3
+      seed: 7
+      transform:
+        - [scale, 0.1, 0.1, 0.1]
+
+- add: plane
+  material:
+    bump:
+      type: normal-map
+      file: normals.ppm
+      strength: 0.5
+      wrap: clamp
+`), 0644)).To(Succeed())
+
+		s, err := scene.LoadFile(path)
+		Expect(err).NotTo(HaveOccurred())
+
+		bump := s.World.Objects[3].Material.Bump.(*geom.HeightBump)
+		Expect(bump.Scale).To(Equal(0.3))
+		Expect(bump.Transform().Matrix().ApproxEq(nmath.NewScaling(0.1, 0.1, 0.1))).To(BeTrue())
+
+		normals := s.World.Objects[4].Material.Bump.(*geom.NormalMap)
+		Expect(normals.Strength).To(Equal(0.5))
+		Expect(normals.Texture.Wrap).To(Equal(geom.WrapClamp))
+		// normal maps are data, they aren't linearized like color textures
+		Expect(normals.Texture.Image.PixelAt(0, 0).B).To(Equal(1.0))
+		Expect(normals.Texture.Image.PixelAt(0, 0).R).To(BeNumerically("~", 128.0/255, 1e-9))
+	})
+
+	DescribeTable("should report errors by line and column",
+		func(text string, line, column int, msg string) {
+			_, err := load(text)
+			var scene_err *scene.Error
+			Expect(err).To(BeAssignableToTypeOf(scene_err))
+			scene_err = err.(*scene.Error)
+			Expect(scene_err.Line).To(Equal(line))
+			Expect(scene_err.Column).To(Equal(column))
+			Expect(scene_err.Msg).To(ContainSubstring(msg))
+		},
+		Entry("unknown key", "- add: sphere\n  colour: [1, 0, 0]\n", 2, 3, `unknown key "colour"`),
+		Entry("bad number", "- add: sphere\n  material:\n    diffuse: lots\n", 3, 14, "expected a number"),
+		Entry("undefined name", "- add: sphere\n  material: shiny\n", 2, 13, `"shiny" is not defined`),
+		Entry("bad transform", "- add: cube\n  transform:\n    - [spin, 1]\n", 3, 8, `unknown transform "spin"`),
+		Entry("wrong arguments", "- add: cube\n  transform:\n    - [translate, 1]\n", 3, 7, "translate needs 3 numbers"),
+		Entry("unknown shape", "- add: teapot\n", 1, 8, `can't add "teapot"`),
+		Entry("unknown choice", "- add: plane\n  material:\n    pattern:\n      type: image\n      file: x.png\n      wrap: tile\n", 6, 13, "expected one of clamp, mirror, repeat"),
+		Entry("missing texture", "- add: plane\n  material:\n    pattern:\n      type: image\n      file: missing.png\n", 5, 13, "missing.png"),
+		Entry("too few colors", "- add: plane\n  material:\n    pattern:\n      type: stripes\n      colors: [[1, 1, 1]]\n", 4, 7, "pattern needs 2 colors"),
+		Entry("missing mask", "- add: plane\n  material:\n    pattern:\n      type: mask\n      colors: [[1, 1, 1], [0, 0, 0]]\n", 4, 7, "needs a mask"),
+		Entry("unknown bump", "- add: plane\n  material:\n    bump:\n      type: dimples\n", 4, 7, `unknown bump type "dimples"`),
+		Entry("missing normal map", "- add: plane\n  material:\n    bump:\n      type: normal-map\n", 4, 7, "needs a file"),
+		Entry("singular transform", "- add: cube\n  transform: [[scale, 0, 1, 1]]\n", 2, 14, "can't be inverted"),
+		Entry("singular pattern transform", "- add: plane\n  material:\n    pattern:\n      type: stripes\n      colors: [[1, 1, 1], [0, 0, 0]]\n      transform:\n        - [scale, 1, 0, 1]\n", 7, 9, "can't be inverted"),
+		Entry("camera looking at itself", "- add: camera\n  width: 10\n  height: 10\n  from: [1, 2, 3]\n  to: [1, 2, 3]\n", 1, 3, "same point"),
+		Entry("camera up along the view", "- add: camera\n  width: 10\n  height: 10\n  from: [0, 0, -5]\n  to: [0, 0, 0]\n  up: [0, 0, 1]\n", 1, 3, "up is along"),
+		Entry("missing camera", "- add: sphere\n", 1, 1, "no camera"),
+		Entry("invalid yaml", "- add: sphere\n\tmaterial: shiny\n", 2, 0, "tab character"),
+	)
+})
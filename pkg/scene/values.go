@@ -0,0 +1,79 @@
+package scene
+
+import (
+	"maps"
+	"slices"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+
+	"github.com/novelalex/soft-raytracer/pkg/nmath"
+)
+
+func decodeScalar[T any](node *yaml.Node, what string) (T, error) {
+	var v T
+	if node.Kind != yaml.ScalarNode || node.Decode(&v) != nil {
+		return v, errorAt(node, "expected %s", what)
+	}
+	return v, nil
+}
+
+func decodeFloat(node *yaml.Node) (float64, error) {
+	return decodeScalar[float64](node, "a number")
+}
+
+func decodeInt(node *yaml.Node) (int, error) {
+	return decodeScalar[int](node, "an integer")
+}
+
+func decodeUint(node *yaml.Node) (uint, error) {
+	return decodeScalar[uint](node, "a positive integer")
+}
+
+func decodeBool(node *yaml.Node) (bool, error) {
+	return decodeScalar[bool](node, "true or false")
+}
+
+func decodeString(node *yaml.Node) (string, error) {
+	return decodeScalar[string](node, "a string")
+}
+
+// decodeChoice is the value of the option named by node
+func decodeChoice[T any](node *yaml.Node, options map[string]T) (T, error) {
+	name, err := decodeString(node)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	v, ok := options[name]
+	if !ok {
+		names := slices.Sorted(maps.Keys(options))
+		return v, errorAt(node, "expected one of %s, not %q", strings.Join(names, ", "), name)
+	}
+	return v, nil
+}
+
+func decodeTriple(node *yaml.Node, what string) ([3]float64, error) {
+	var v [3]float64
+	if node.Kind != yaml.SequenceNode || len(node.Content) != 3 {
+		return v, errorAt(node, "expected %s as a list of 3 numbers", what)
+	}
+	for i, n := range node.Content {
+		f, err := decodeFloat(n)
+		if err != nil {
+			return v, err
+		}
+		v[i] = f
+	}
+	return v, nil
+}
+
+func decodeVec3(node *yaml.Node) (nmath.Vec3, error) {
+	v, err := decodeTriple(node, "a vector")
+	return nmath.NewVec3(v[0], v[1], v[2]), err
+}
+
+func decodeColor(node *yaml.Node) (nmath.Color, error) {
+	v, err := decodeTriple(node, "a color")
+	return nmath.NewColor(v[0], v[1], v[2]), err
+}